@@ -0,0 +1,198 @@
+package discom
+
+import (
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// InteractionContextType where a command can be used
+type InteractionContextType int
+
+const (
+	// InteractionContextGuild in a guild
+	InteractionContextGuild InteractionContextType = iota
+	// InteractionContextBotDM in a direct message with the bot
+	InteractionContextBotDM
+	// InteractionContextPrivateChannel in group DMs and DMs other than the bot's
+	InteractionContextPrivateChannel
+)
+
+// AppCommand a command as it is registered with discord.
+// discordgo.ApplicationCommand is missing contexts and drops fields which are unset
+// so they can never be cleared by an edit.
+type AppCommand struct {
+	ID                       string                                `json:"id,omitempty"`
+	ApplicationID            string                                `json:"application_id,omitempty"`
+	GuildID                  string                                `json:"guild_id,omitempty"`
+	Version                  string                                `json:"version,omitempty"`
+	Type                     discordgo.ApplicationCommandType      `json:"type,omitempty"`
+	Name                     string                                `json:"name"`
+	NameLocalizations        map[discordgo.Locale]string           `json:"name_localizations"`
+	Description              string                                `json:"description"`
+	DescriptionLocalizations map[discordgo.Locale]string           `json:"description_localizations"`
+	Options                  []*discordgo.ApplicationCommandOption `json:"options"`
+	DefaultMemberPermissions *int64                                `json:"default_member_permissions,string"`
+	DMPermission             *bool                                 `json:"dm_permission,omitempty"`
+	NSFW                     bool                                  `json:"nsfw"`
+	Contexts                 []InteractionContextType              `json:"contexts"`
+}
+
+// CommandKey identifies a command within a guild.
+// Discord allows a chat, user and message command to share a name so the
+// names of user and message commands are prefixed with "user:" and "message:".
+func CommandKey(cmdType discordgo.ApplicationCommandType, name string) string {
+	switch cmdType {
+	case discordgo.UserApplicationCommand:
+		return "user:" + name
+	case discordgo.MessageApplicationCommand:
+		return "message:" + name
+	}
+
+	return name
+}
+
+func (c *AppCommand) key() string {
+	return CommandKey(c.Type, c.Name)
+}
+
+// normalised a copy of the command with discord's defaults filled in so commands
+// which discord treats the same compare equal
+func (c *AppCommand) normalised(guildID string) *AppCommand {
+	result := *c
+	if result.Type == 0 {
+		result.Type = discordgo.ChatApplicationCommand
+	}
+
+	if len(result.NameLocalizations) == 0 {
+		result.NameLocalizations = nil
+	}
+
+	if len(result.DescriptionLocalizations) == 0 {
+		result.DescriptionLocalizations = nil
+	}
+
+	// dm_permission is ignored for guild commands
+	if guildID != "" {
+		result.DMPermission = nil
+	} else if result.DMPermission == nil {
+		allowed := true
+		result.DMPermission = &allowed
+	}
+
+	if len(result.Contexts) == 0 {
+		result.Contexts = nil
+	}
+
+	result.Options = normaliseOptions(result.Options)
+
+	return &result
+}
+
+func normaliseOptions(options []*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+	if len(options) == 0 {
+		return nil
+	}
+
+	result := make([]*discordgo.ApplicationCommandOption, len(options))
+	for i, option := range options {
+		normalised := *option
+		if len(normalised.NameLocalizations) == 0 {
+			normalised.NameLocalizations = nil
+		}
+
+		if len(normalised.DescriptionLocalizations) == 0 {
+			normalised.DescriptionLocalizations = nil
+		}
+
+		if len(normalised.ChannelTypes) == 0 {
+			normalised.ChannelTypes = nil
+		}
+
+		if len(normalised.Choices) == 0 {
+			normalised.Choices = nil
+		} else {
+			normalised.Choices = make([]*discordgo.ApplicationCommandOptionChoice, len(option.Choices))
+			for j, choice := range option.Choices {
+				normalisedChoice := *choice
+				if len(normalisedChoice.NameLocalizations) == 0 {
+					normalisedChoice.NameLocalizations = nil
+				}
+				normalised.Choices[j] = &normalisedChoice
+			}
+		}
+
+		normalised.Options = normaliseOptions(option.Options)
+		result[i] = &normalised
+	}
+
+	return result
+}
+
+func appCommandsEndpoint(appID, guildID string) string {
+	if guildID != "" {
+		return discordgo.EndpointApplicationGuildCommands(appID, guildID)
+	}
+
+	return discordgo.EndpointApplicationGlobalCommands(appID)
+}
+
+func appCommandEndpoint(appID, guildID, cmdID string) string {
+	if guildID != "" {
+		return discordgo.EndpointApplicationGuildCommand(appID, guildID, cmdID)
+	}
+
+	return discordgo.EndpointApplicationGlobalCommand(appID, cmdID)
+}
+
+// appCommands the commands registered in the guild "" being global
+func appCommands(s *discordgo.Session, appID, guildID string) ([]*AppCommand, error) {
+	endpoint := appCommandsEndpoint(appID, guildID)
+	body, err := s.RequestWithBucketID("GET", endpoint+"?with_localizations=true", nil, "GET "+endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*AppCommand
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func createAppCommand(s *discordgo.Session, appID, guildID string, cmd *AppCommand) (*AppCommand, error) {
+	endpoint := appCommandsEndpoint(appID, guildID)
+	body, err := s.RequestWithBucketID("POST", endpoint, cmd, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *AppCommand
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func editAppCommand(s *discordgo.Session, appID, guildID, cmdID string, cmd *AppCommand) error {
+	endpoint := appCommandEndpoint(appID, guildID, cmdID)
+	_, err := s.RequestWithBucketID("PATCH", endpoint, cmd, endpoint)
+	return err
+}
+
+func bulkOverwriteAppCommands(s *discordgo.Session, appID, guildID string, cmds []*AppCommand) ([]*AppCommand, error) {
+	endpoint := appCommandsEndpoint(appID, guildID)
+	body, err := s.RequestWithBucketID("PUT", endpoint, cmds, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*AppCommand
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
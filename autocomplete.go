@@ -0,0 +1,80 @@
+package discom
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAutocompleteChoices the most choices discord will accept in an autocomplete result
+const maxAutocompleteChoices = 25
+
+// AutocompleteHandler called while a user is typing an option which has Autocomplete set.
+// focused is the option being typed and partial is what has been typed so far.
+// Only the first 25 choices are sent to discord.
+type AutocompleteHandler func(s *discordgo.Session, i Interaction, focused *discordgo.ApplicationCommandInteractionDataOption, partial string) ([]*discordgo.ApplicationCommandOptionChoice, error)
+
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, option := range options {
+		if option.Focused {
+			return option
+		}
+	}
+
+	return nil
+}
+
+// autocomplete answers an autocomplete interaction using the invoked command's Autocomplete handler.
+// If the handler errors no choices are shown and the error is passed to the ErrorHandler.
+func (cs *CommandSet) autocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	com := cs.findCommand(i.ApplicationCommandData().CommandType, i.ApplicationCommandData().Name, i.GuildID)
+	if com == nil {
+		return
+	}
+
+	cmd, options := com.findSubCommand(i.ApplicationCommandData().Options)
+	if cmd == nil || cmd.Autocomplete == nil {
+		return
+	}
+
+	focused := focusedOption(options)
+	if focused == nil {
+		return
+	}
+
+	var partial string
+	if focused.Value != nil {
+		partial = fmt.Sprint(focused.Value)
+	}
+
+	inter := &discordInteraction{
+		interaction: i.Interaction,
+		options:     options,
+	}
+
+	done, ok := cs.begin(inter, commandPath(com, cmd), cmd.Timeout)
+	if !ok {
+		return
+	}
+	defer done()
+
+	choices, err := cmd.Autocomplete(s, inter, focused, partial)
+	if len(choices) > maxAutocompleteChoices {
+		choices = choices[:maxAutocompleteChoices]
+	}
+
+	respondErr := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+
+	if err == nil {
+		err = respondErr
+	}
+
+	if err != nil {
+		cs.ErrorHandler(s, inter, err)
+	}
+}
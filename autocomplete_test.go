@@ -0,0 +1,72 @@
+package discom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutocomplete(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	called := false
+	var focusedName, partial string
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "film",
+		Description: "pick a film",
+		Handler: func(*discordgo.Session, Interaction) error {
+			called = true
+			return nil
+		},
+		Autocomplete: func(s *discordgo.Session, i Interaction, focused *discordgo.ApplicationCommandInteractionDataOption, value string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+			focusedName, partial = focused.Name, value
+
+			var result []*discordgo.ApplicationCommandOptionChoice
+			for i := 0; i < 30; i++ {
+				name := fmt.Sprintf("%s %d", value, i)
+				result = append(result, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+			}
+			return result, nil
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{Name: "title", Description: "title", Type: discordgo.ApplicationCommandOptionString, Autocomplete: true},
+		},
+	}))
+
+	s, transport := newTestSession()
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommandAutocomplete,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "film",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "title", Type: discordgo.ApplicationCommandOptionString, Value: "bee", Focused: true},
+				},
+			},
+		},
+	})
+
+	assert.False(t, called)
+	assert.Equal(t, "title", focusedName)
+	assert.Equal(t, "bee", partial)
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Path, "/interactions/interactionID/token/callback")
+	assert.Contains(t, requests[0].Body, `"type":8`)
+	assert.Contains(t, requests[0].Body, `"bee 24"`)
+	assert.NotContains(t, requests[0].Body, `"bee 25"`)
+
+	// Autocomplete options need a handler
+	assert.Error(t, cs.AddCommand(Command{
+		Name:    "bad",
+		Handler: func(*discordgo.Session, Interaction) error { return nil },
+		Options: []*discordgo.ApplicationCommandOption{
+			{Name: "title", Type: discordgo.ApplicationCommandOptionString, Autocomplete: true},
+		},
+	}))
+}
@@ -0,0 +1,100 @@
+package discom
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Check decides whether a command can be run.
+// Returning an error stops the command, the error's message is the reason given to the user.
+type Check func(*discordgo.Session, Interaction) error
+
+// CheckError passed to the ErrorHandler when a check stops a command
+type CheckError struct {
+	Command string
+	// Reason the error the check returned
+	Reason error
+}
+
+func (e *CheckError) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Reason
+}
+
+// HasRole a check which passes if the author has any of the roles
+func HasRole(roleIDs ...string) Check {
+	return func(_ *discordgo.Session, i Interaction) error {
+		for _, role := range i.GetPayload().AuthorRoleIds {
+			for _, id := range roleIDs {
+				if role == id {
+					return nil
+				}
+			}
+		}
+
+		return fmt.Errorf("you do not have the role needed to use this command")
+	}
+}
+
+// IsUser a check which passes if the author is one of the users e.g. the bot's owners
+func IsUser(userIDs ...string) Check {
+	return func(_ *discordgo.Session, i Interaction) error {
+		author := i.GetPayload().AuthorId
+		for _, id := range userIDs {
+			if author == id {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("you are not allowed to use this command")
+	}
+}
+
+// InChannel a check which passes if the command is used in one of the channels
+func InChannel(channelIDs ...string) Check {
+	return func(_ *discordgo.Session, i Interaction) error {
+		channel := i.GetPayload().ChannelId
+		for _, id := range channelIDs {
+			if channel == id {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("this command cannot be used in this channel")
+	}
+}
+
+// pathTo the commands from c down to the sub command target
+func (c *Command) pathTo(target *Command) []*Command {
+	if c == target {
+		return []*Command{c}
+	}
+
+	for i := range c.SubCommands {
+		if path := c.SubCommands[i].pathTo(target); path != nil {
+			return append([]*Command{c}, path...)
+		}
+	}
+
+	return nil
+}
+
+// runChecks runs the command set's checks then the checks of each command from the root down to cmd
+func (cs *CommandSet) runChecks(s *discordgo.Session, root, cmd *Command, inter Interaction) error {
+	checks := append([]Check(nil), cs.Checks...)
+	for _, com := range root.pathTo(cmd) {
+		checks = append(checks, com.Checks...)
+	}
+
+	for _, check := range checks {
+		if err := check(s, inter); err != nil {
+			return &CheckError{Command: cmd.Name, Reason: err}
+		}
+	}
+
+	return nil
+}
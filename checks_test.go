@@ -0,0 +1,117 @@
+package discom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecks(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	var ran []string
+	handler := func(name string) CommandHandler {
+		return func(*discordgo.Session, Interaction) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	errBanned := fmt.Errorf("you are banned")
+	cs.Checks = []Check{func(_ *discordgo.Session, i Interaction) error {
+		if i.GetPayload().AuthorId == "bannedID" {
+			return errBanned
+		}
+		return nil
+	}}
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:    "owner",
+		Handler: handler("owner"),
+		Checks:  []Check{IsUser("ownerID")},
+	}))
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:   "config",
+		Checks: []Check{HasRole("adminRoleID")},
+		SubCommands: []Command{
+			{Name: "set", Handler: handler("set"), Checks: []Check{InChannel("configChannelID")}},
+		},
+	}))
+
+	s, _ := newTestSession()
+
+	prefix := func(authorID, channelID string, roles []string, content string) error {
+		ran, errored = nil, nil
+		cs.Handler(s, &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				GuildID:   "guildID",
+				ChannelID: channelID,
+				Author:    &discordgo.User{ID: authorID},
+				Member:    &discordgo.Member{Roles: roles},
+				Content:   content,
+			},
+		})
+		return errored
+	}
+
+	slash := func(authorID, channelID string, roles []string, data discordgo.ApplicationCommandInteractionData) error {
+		ran, errored = nil, nil
+		cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:        "interactionID",
+				Token:     "token",
+				Type:      discordgo.InteractionApplicationCommand,
+				GuildID:   "guildID",
+				ChannelID: channelID,
+				Member:    &discordgo.Member{User: &discordgo.User{ID: authorID}, Roles: roles},
+				Data:      data,
+			},
+		})
+		return errored
+	}
+
+	ownerData := discordgo.ApplicationCommandInteractionData{Name: "owner"}
+	setData := discordgo.ApplicationCommandInteractionData{
+		Name: "config",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "set", Type: discordgo.ApplicationCommandOptionSubCommand},
+		},
+	}
+
+	for _, err := range []error{
+		prefix("userID", "channelID", nil, "test$ owner"),
+		slash("userID", "channelID", nil, ownerData),
+	} {
+		var checkErr *CheckError
+		assert.True(t, errors.As(err, &checkErr))
+		assert.Equal(t, "owner", checkErr.Command)
+		assert.EqualError(t, err, "you are not allowed to use this command")
+		assert.Empty(t, ran)
+	}
+
+	assert.NoError(t, prefix("ownerID", "channelID", nil, "test$ owner"))
+	assert.Equal(t, []string{"owner"}, ran)
+	assert.NoError(t, slash("ownerID", "channelID", nil, ownerData))
+	assert.Equal(t, []string{"owner"}, ran)
+
+	// Command set checks run first
+	err := slash("bannedID", "channelID", nil, ownerData)
+	assert.True(t, errors.Is(err, errBanned))
+
+	// Parent checks apply to sub commands
+	err = prefix("userID", "configChannelID", nil, "test$ config set")
+	assert.EqualError(t, err, "you do not have the role needed to use this command")
+	err = slash("userID", "channelID", []string{"adminRoleID"}, setData)
+	assert.EqualError(t, err, "this command cannot be used in this channel")
+
+	assert.NoError(t, prefix("userID", "configChannelID", []string{"adminRoleID"}, "test$ config set"))
+	assert.Equal(t, []string{"set"}, ran)
+	assert.NoError(t, slash("userID", "configChannelID", []string{"adminRoleID"}, setData))
+	assert.Equal(t, []string{"set"}, ran)
+}
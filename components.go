@@ -0,0 +1,163 @@
+package discom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUpdateAfterDefer Update was called after Defer which responds with a new message use DeferUpdate instead
+	ErrUpdateAfterDefer = fmt.Errorf("cannot update the message after defer")
+
+	componentParamPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// ComponentInteraction a button click or select menu choice
+type ComponentInteraction interface {
+	Interaction
+	// Update replaces the message the component is attached to instead of sending a new message
+	Update(*discordgo.Session, Response) error
+	// DeferUpdate acknowledges the interaction without changing the message so Update can be called later.
+	// Use this instead of Defer when the handler will Update.
+	DeferUpdate(*discordgo.Session) error
+	// CustomID the full custom id of the component
+	CustomID() string
+	// Param a value captured by a {name} in the route's pattern
+	Param(name string) string
+	// Values the selected values of a select menu
+	Values() []string
+}
+
+// ComponentHandler called when a button or select menu matching a route is used
+// Error should only return data your fine with the user seeing
+type ComponentHandler func(*discordgo.Session, ComponentInteraction) error
+
+type componentRoute struct {
+	pattern *regexp.Regexp
+	handler ComponentHandler
+}
+
+// compileComponentPattern converts a custom id pattern into a regex.
+// {name} captures a parameter and a trailing * matches any suffix e.g. "vote:{poll}:*".
+func compileComponentPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid pattern is empty")
+	}
+
+	prefix := strings.HasSuffix(pattern, "*")
+	pattern = strings.TrimSuffix(pattern, "*")
+
+	var result strings.Builder
+	result.WriteString("^")
+	last := 0
+	for _, match := range componentParamPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		result.WriteString(regexp.QuoteMeta(pattern[last:match[0]]))
+		fmt.Fprintf(&result, "(?P<%s>.+?)", pattern[match[2]:match[3]])
+		last = match[1]
+	}
+	result.WriteString(regexp.QuoteMeta(pattern[last:]))
+	if prefix {
+		result.WriteString(".*")
+	}
+	result.WriteString("$")
+
+	return regexp.Compile(result.String())
+}
+
+// matchCustomID returns the parameters captured from the custom id if it matches the pattern
+func matchCustomID(pattern *regexp.Regexp, customID string) (map[string]string, bool) {
+	match := pattern.FindStringSubmatch(customID)
+	if match == nil {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for idx, name := range pattern.SubexpNames() {
+		if name != "" {
+			params[name] = match[idx]
+		}
+	}
+
+	return params, true
+}
+
+// AddComponent Use this to handle buttons and select menus whose custom id matches pattern.
+// Parameters are declared with {name} and a trailing * matches any suffix e.g. "vote:{poll}:*".
+// Routes are checked in the order they are added.
+func (cs *CommandSet) AddComponent(pattern string, handler ComponentHandler) error {
+	if handler == nil {
+		return fmt.Errorf("invalid handler is nil")
+	}
+
+	compiled, err := compileComponentPattern(pattern)
+	if err != nil {
+		return errors.Wrap(err, "invalid component pattern")
+	}
+
+	cs.components = append(cs.components, componentRoute{
+		pattern: compiled,
+		handler: handler,
+	})
+
+	return nil
+}
+
+func (cs *CommandSet) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	for _, route := range cs.components {
+		params, ok := matchCustomID(route.pattern, data.CustomID)
+		if !ok {
+			continue
+		}
+
+		inter := &discordComponent{
+			discordInteraction: discordInteraction{
+				interaction: i.Interaction,
+				overflow:    cs.overflow(nil),
+			},
+			data:   data,
+			params: params,
+		}
+
+		done, ok := cs.begin(inter, data.CustomID, 0)
+		if !ok {
+			return
+		}
+		defer done()
+
+		if err := route.handler(s, inter); err != nil {
+			cs.ErrorHandler(s, inter, err)
+		}
+		return
+	}
+}
+
+type discordComponent struct {
+	discordInteraction
+	data   discordgo.MessageComponentInteractionData
+	params map[string]string
+}
+
+func (d *discordComponent) CustomID() string {
+	return d.data.CustomID
+}
+
+func (d *discordComponent) Param(name string) string {
+	return d.params[name]
+}
+
+func (d *discordComponent) Values() []string {
+	return d.data.Values
+}
+
+func (d *discordComponent) Update(s *discordgo.Session, res Response) error {
+	return d.update(s, res)
+}
+
+func (d *discordComponent) DeferUpdate(s *discordgo.Session) error {
+	return d.deferUpdate(s)
+}
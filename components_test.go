@@ -0,0 +1,114 @@
+package discom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func componentInteraction(customID string, values ...string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionMessageComponent,
+			User:  &discordgo.User{ID: "userID"},
+			Data: discordgo.MessageComponentInteractionData{
+				CustomID: customID,
+				Values:   values,
+			},
+		},
+	}
+}
+
+func TestComponents(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	var poll, choice, author string
+	assert.NoError(t, cs.AddComponent("vote:{poll}:{choice}", func(s *discordgo.Session, i ComponentInteraction) error {
+		poll, choice = i.Param("poll"), i.Param("choice")
+		author = i.GetPayload().AuthorId
+		return i.Update(s, Response{Content: "voted"})
+	}))
+
+	var values []string
+	assert.NoError(t, cs.AddComponent("menu*", func(s *discordgo.Session, i ComponentInteraction) error {
+		values = i.Values()
+		if len(values) == 0 {
+			return fmt.Errorf("nothing selected")
+		}
+		return i.Respond(s, Response{Content: "picked"})
+	}))
+
+	assert.Error(t, cs.AddComponent("", func(*discordgo.Session, ComponentInteraction) error { return nil }))
+
+	s, transport := newTestSession()
+
+	cs.IntreactionHandler(s, componentInteraction("vote:42:yes"))
+	assert.Equal(t, "42", poll)
+	assert.Equal(t, "yes", choice)
+	assert.Equal(t, "userID", author)
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, `"type":7`)
+
+	cs.IntreactionHandler(s, componentInteraction("menu.films", "bee", "shrek"))
+	assert.Equal(t, []string{"bee", "shrek"}, values)
+
+	requests = transport.Requests()
+	assert.Len(t, requests, 2)
+	assert.Contains(t, requests[1].Body, `"type":4`)
+
+	// Errors go to the error handler
+	cs.IntreactionHandler(s, componentInteraction("menu"))
+	assert.EqualError(t, errored, "nothing selected")
+
+	// Unknown custom ids are ignored
+	poll = ""
+	cs.IntreactionHandler(s, componentInteraction("vote:42"))
+	assert.Equal(t, "", poll)
+}
+
+func TestComponentDeferUpdate(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	assert.NoError(t, cs.AddComponent("slow", func(s *discordgo.Session, i ComponentInteraction) error {
+		if err := i.DeferUpdate(s); err != nil {
+			return err
+		}
+		return i.Update(s, Response{Content: "updated"})
+	}))
+	assert.NoError(t, cs.AddComponent("wrong", func(s *discordgo.Session, i ComponentInteraction) error {
+		if err := i.Defer(s); err != nil {
+			return err
+		}
+		return i.Update(s, Response{Content: "updated"})
+	}))
+
+	s, transport := newTestSession()
+
+	cs.IntreactionHandler(s, componentInteraction("slow"))
+	requests := transport.Requests()
+	assert.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Body, `"type":6`)
+	assert.Equal(t, "PATCH", requests[1].Method)
+	assert.Contains(t, requests[1].Path, "/messages/@original")
+	assert.Contains(t, requests[1].Body, `"content":"updated"`)
+	assert.NoError(t, errored)
+
+	// Defer sends a new message which Update must not edit
+	cs.IntreactionHandler(s, componentInteraction("wrong"))
+	requests = transport.Requests()[2:]
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, `"type":5`)
+	assert.Equal(t, ErrUpdateAfterDefer, errored)
+}
@@ -0,0 +1,181 @@
+package discom
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// ChoiceError returned when a prefix argument isn't one of the option's choices
+type ChoiceError struct {
+	Option  string
+	Given   string
+	Choices []string
+}
+
+func (e *ChoiceError) Error() string {
+	return fmt.Sprintf("%s must be one of %s but was given %s", e.Option, strings.Join(e.Choices, ", "), e.Given)
+}
+
+func (e *ChoiceError) Unwrap() error {
+	return ErrInvalidArg
+}
+
+// RangeError returned when a prefix argument is outside of the option's MinValue or MaxValue
+type RangeError struct {
+	Option string
+	Value  float64
+	// Min nil if there is no minimum
+	Min *float64
+	// Max nil if there is no maximum
+	Max *float64
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("%s must be %s but was given %v", e.Option, describeBounds(e.Min, e.Max), e.Value)
+}
+
+func (e *RangeError) Unwrap() error {
+	return ErrInvalidArg
+}
+
+// LengthError returned when a prefix argument is shorter than the option's MinLength or longer than its MaxLength
+type LengthError struct {
+	Option string
+	Length int
+	// Min nil if there is no minimum
+	Min *int
+	// Max nil if there is no maximum
+	Max *int
+}
+
+func (e *LengthError) Error() string {
+	var min, max *float64
+	if e.Min != nil {
+		val := float64(*e.Min)
+		min = &val
+	}
+	if e.Max != nil {
+		val := float64(*e.Max)
+		max = &val
+	}
+
+	return fmt.Sprintf("%s must be %s characters long but was %d", e.Option, describeBounds(min, max), e.Length)
+}
+
+func (e *LengthError) Unwrap() error {
+	return ErrInvalidArg
+}
+
+func describeBounds(min, max *float64) string {
+	switch {
+	case min != nil && max != nil:
+		return fmt.Sprintf("between %v and %v", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("at least %v", *min)
+	case max != nil:
+		return fmt.Sprintf("at most %v", *max)
+	}
+
+	return "anything"
+}
+
+// normaliseChoiceValue converts a choice value into the form discord sends it in
+// every kind of number being sent as a float64
+func normaliseChoiceValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+
+	return value
+}
+
+// matchChoice maps an argument onto one of the option's choices.
+// The argument can either be the name of the choice (case-insensitive) or its value.
+func (ctx argContext) matchChoice(option *discordgo.ApplicationCommandOption, arg string) (interface{}, error) {
+	for _, choice := range option.Choices {
+		if strings.EqualFold(choice.Name, arg) {
+			return normaliseChoiceValue(choice.Value), nil
+		}
+	}
+
+	value, err := ctx.parseOptionValue(option, arg)
+	if err == nil {
+		for _, choice := range option.Choices {
+			if normaliseChoiceValue(choice.Value) == value {
+				return value, nil
+			}
+		}
+	}
+
+	names := make([]string, len(option.Choices))
+	for i, choice := range option.Choices {
+		names[i] = choice.Name
+	}
+
+	return nil, &ChoiceError{
+		Option:  option.Name,
+		Given:   arg,
+		Choices: names,
+	}
+}
+
+// checkConstraints enforces the min/max value and length limits discord applies to slash commands
+func checkConstraints(option *discordgo.ApplicationCommandOption, value interface{}) error {
+	switch option.Type {
+	case discordgo.ApplicationCommandOptionInteger, discordgo.ApplicationCommandOptionNumber:
+		number, ok := value.(float64)
+		if !ok {
+			return errors.Wrapf(ErrInvalidArg, "%s expected a number but has the value %v", option.Name, value)
+		}
+
+		var max *float64
+		// discordgo uses 0 for no maximum
+		if option.MaxValue != 0 {
+			max = &option.MaxValue
+		}
+
+		if (option.MinValue != nil && number < *option.MinValue) || (max != nil && number > *max) {
+			return &RangeError{
+				Option: option.Name,
+				Value:  number,
+				Min:    option.MinValue,
+				Max:    max,
+			}
+		}
+
+	case discordgo.ApplicationCommandOptionString:
+		str, ok := value.(string)
+		if !ok {
+			return errors.Wrapf(ErrInvalidArg, "%s expected a string but has the value %v", option.Name, value)
+		}
+
+		length := utf8.RuneCountInString(str)
+		var max *int
+		// discordgo uses 0 for no maximum
+		if option.MaxLength != 0 {
+			max = &option.MaxLength
+		}
+
+		if (option.MinLength != nil && length < *option.MinLength) || (max != nil && length > *max) {
+			return &LengthError{
+				Option: option.Name,
+				Length: length,
+				Min:    option.MinLength,
+				Max:    max,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraints(t *testing.T) {
+	min := 1.0
+	minLength := 2
+	cmd := Command{
+		Name: "nice",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name: "colour",
+				Type: discordgo.ApplicationCommandOptionString,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Red", Value: "#ff0000"},
+					{Name: "Green", Value: "#00ff00"},
+				},
+			},
+			{
+				Name: "size",
+				Type: discordgo.ApplicationCommandOptionInteger,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Small", Value: 1},
+					{Name: "Large", Value: 10},
+				},
+			},
+			{
+				Name:     "sides",
+				Type:     discordgo.ApplicationCommandOptionInteger,
+				MinValue: &min,
+				MaxValue: 20,
+			},
+			{
+				Name:      "name",
+				Type:      discordgo.ApplicationCommandOptionString,
+				MinLength: &minLength,
+				MaxLength: 4,
+			},
+		},
+	}
+
+	options, err := cmd.parseArgs(argContext{}, []string{"-colour", "red", "-size", "LARGE"})
+	assert.NoError(t, err)
+	assert.Equal(t, "#ff0000", genOptionsMap(options)["colour"].StringValue())
+	assert.Equal(t, int64(10), genOptionsMap(options)["size"].IntValue())
+
+	options, err = cmd.parseArgs(argContext{}, []string{"-colour", "#00ff00", "-size", "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "#00ff00", genOptionsMap(options)["colour"].StringValue())
+	assert.Equal(t, int64(1), genOptionsMap(options)["size"].IntValue())
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-colour", "blue"})
+	var choiceErr *ChoiceError
+	assert.True(t, errors.As(err, &choiceErr))
+	assert.Equal(t, "colour", choiceErr.Option)
+	assert.Equal(t, []string{"Red", "Green"}, choiceErr.Choices)
+	assert.True(t, errors.Is(err, ErrInvalidArg))
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-sides", "20", "-name", "蜜蜂"})
+	assert.NoError(t, err)
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-sides", "21"})
+	var rangeErr *RangeError
+	assert.True(t, errors.As(err, &rangeErr))
+	assert.Equal(t, "sides", rangeErr.Option)
+	assert.EqualError(t, err, "sides must be between 1 and 20 but was given 21")
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-sides", "0"})
+	assert.True(t, errors.As(err, &rangeErr))
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-name", "a"})
+	var lengthErr *LengthError
+	assert.True(t, errors.As(err, &lengthErr))
+	assert.Equal(t, 1, lengthErr.Length)
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-name", "paul sarda"})
+	assert.EqualError(t, err, "name must be between 2 and 4 characters long but was 10")
+}
+
+func TestChoiceValueKinds(t *testing.T) {
+	cmd := Command{
+		Name: "pick",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name: "count",
+				Type: discordgo.ApplicationCommandOptionInteger,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "one", Value: int16(1)},
+					{Name: "two", Value: uint8(2)},
+					{Name: "three", Value: int8(3)},
+					{Name: "four", Value: uint16(4)},
+				},
+			},
+		},
+	}
+
+	for arg, expected := range map[string]int64{"one": 1, "TWO": 2, "3": 3, "four": 4} {
+		options, err := cmd.parseArgs(argContext{}, []string{arg})
+		assert.NoError(t, err, arg)
+		assert.Equal(t, expected, genOptionsMap(options)["count"].IntValue(), arg)
+	}
+
+	// A choice value which doesn't match the option type is an error not a panic
+	cmd.Options[0].Choices = []*discordgo.ApplicationCommandOptionChoice{{Name: "one", Value: "1"}}
+	assert.NotPanics(t, func() {
+		_, err := cmd.parseArgs(argContext{}, []string{"one"})
+		assert.True(t, errors.Is(err, ErrInvalidArg))
+	})
+}
@@ -0,0 +1,127 @@
+package discom
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Invocation what is being run it can be found in the context of every Interaction
+type Invocation struct {
+	// Command the full name of the command e.g. "config set" or the custom id of a component or modal
+	Command string
+	// CorrelationID the id of the interaction or message which caused the invocation
+	CorrelationID string
+	// Started when the invocation started
+	Started time.Time
+}
+
+type invocationKey struct{}
+
+// InvocationFromContext the invocation the context belongs to nil if it isn't from an Interaction
+func InvocationFromContext(ctx context.Context) *Invocation {
+	invocation, _ := ctx.Value(invocationKey{}).(*Invocation)
+	return invocation
+}
+
+// invokable an Interaction which the command set can give a context
+type invokable interface {
+	Interaction
+	correlationID() string
+	setContext(ctx context.Context)
+}
+
+func (d *discordInteraction) Context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+
+	return d.ctx
+}
+
+func (d *discordInteraction) correlationID() string {
+	return d.interaction.ID
+}
+
+func (d *discordInteraction) setContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+func (d *discordMessage) Context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+
+	return d.ctx
+}
+
+func (d *discordMessage) correlationID() string {
+	return d.message.ID
+}
+
+func (d *discordMessage) setContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+// commandPath the full name of the sub command cmd e.g. "config set"
+func commandPath(root, cmd *Command) string {
+	var names []string
+	for _, com := range root.pathTo(cmd) {
+		names = append(names, com.Name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// begin gives the interaction a context for the invocation which is cancelled after timeout
+// or when the command set is shut down. done must be called once the invocation has finished.
+// ok is false if the command set has already been shut down.
+func (cs *CommandSet) begin(inter invokable, name string, timeout time.Duration) (done func(), ok bool) {
+	cs.runningMu.Lock()
+	defer cs.runningMu.Unlock()
+
+	if cs.ctx.Err() != nil {
+		return nil, false
+	}
+	cs.running.Add(1)
+
+	ctx := context.WithValue(cs.ctx, invocationKey{}, &Invocation{
+		Command:       name,
+		CorrelationID: inter.correlationID(),
+		Started:       time.Now(),
+	})
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	inter.setContext(ctx)
+
+	return func() {
+		cancel()
+		cs.running.Done()
+	}, true
+}
+
+// Shutdown cancels the context of every running command and stops new commands from running.
+// It waits for the running commands to return or ctx to be done.
+func (cs *CommandSet) Shutdown(ctx context.Context) error {
+	cs.runningMu.Lock()
+	cs.cancel()
+	cs.runningMu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		cs.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
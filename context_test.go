@@ -0,0 +1,127 @@
+package discom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInteractionContext(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	var invocation *Invocation
+	assert.NoError(t, cs.AddCommand(Command{
+		Name: "config",
+		SubCommands: []Command{
+			{Name: "set", Handler: func(_ *discordgo.Session, i Interaction) error {
+				invocation = InvocationFromContext(i.Context())
+				return nil
+			}},
+		},
+	}))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Handler: func(_ *discordgo.Session, i Interaction) error {
+			<-i.Context().Done()
+			return i.Context().Err()
+		},
+	}))
+
+	assert.NoError(t, cs.AddComponent("vote:{poll}", func(_ *discordgo.Session, i ComponentInteraction) error {
+		invocation = InvocationFromContext(i.Context())
+		return nil
+	}))
+
+	s, _ := newTestSession()
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			User:  &discordgo.User{ID: "userID"},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "config",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "set", Type: discordgo.ApplicationCommandOptionSubCommand},
+				},
+			},
+		},
+	})
+	assert.Equal(t, "config set", invocation.Command)
+	assert.Equal(t, "interactionID", invocation.CorrelationID)
+	assert.False(t, invocation.Started.IsZero())
+
+	message := func(content string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				ID:        "messageID",
+				ChannelID: "channelID",
+				Author:    &discordgo.User{ID: "userID"},
+				Content:   content,
+			},
+		}
+	}
+
+	cs.Handler(s, message("test$ config set"))
+	assert.Equal(t, "config set", invocation.Command)
+	assert.Equal(t, "messageID", invocation.CorrelationID)
+
+	cs.IntreactionHandler(s, componentInteraction("vote:42"))
+	assert.Equal(t, "vote:42", invocation.Command)
+
+	// The context is cancelled after the command's timeout
+	cs.Handler(s, message("test$ slow"))
+	assert.Equal(t, context.DeadlineExceeded, errored)
+
+	assert.Nil(t, InvocationFromContext(context.Background()))
+}
+
+func TestShutdown(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	started := make(chan struct{})
+	runs := 0
+	assert.NoError(t, cs.AddCommand(Command{
+		Name: "wait",
+		Handler: func(_ *discordgo.Session, i Interaction) error {
+			runs++
+			close(started)
+			<-i.Context().Done()
+			return nil
+		},
+	}))
+
+	s, _ := newTestSession()
+	message := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "userID"},
+			Content:   "test$ wait",
+		},
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		cs.Handler(s, message)
+		close(finished)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, cs.Shutdown(ctx))
+	<-finished
+
+	// Commands don't run once the command set is shut down
+	cs.Handler(s, message)
+	assert.Equal(t, 1, runs)
+}
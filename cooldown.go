@@ -0,0 +1,213 @@
+package discom
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrCooldown the command has been used too many times recently
+	ErrCooldown = fmt.Errorf("on cooldown")
+)
+
+// CooldownError passed to the ErrorHandler when a command is on cooldown
+type CooldownError struct {
+	Command string
+	// RetryAfter how long until the command can be used again
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("%s is on cooldown try again in %s", e.Command, e.RetryAfter.Round(time.Second))
+}
+
+func (e *CooldownError) Unwrap() error {
+	return ErrCooldown
+}
+
+// CooldownBucket who shares a cooldown
+type CooldownBucket int
+
+const (
+	// CooldownUser each user has their own cooldown
+	CooldownUser CooldownBucket = iota
+	// CooldownChannel everyone in a channel shares a cooldown
+	CooldownChannel
+	// CooldownGuild everyone in a guild shares a cooldown DMs use the channel
+	CooldownGuild
+	// CooldownGlobal everyone shares one cooldown
+	CooldownGlobal
+)
+
+// Cooldown limits a command to Uses every Window
+type Cooldown struct {
+	Uses   int
+	Window time.Duration
+	Bucket CooldownBucket
+	// Bypass if any of these pass the cooldown doesn't apply e.g. IsUser for the bot's owners
+	Bypass []Check
+}
+
+// CooldownStore keeps track of how many times each cooldown has been used
+type CooldownStore interface {
+	// Take uses the cooldown for key once returning how long until it can be used again
+	// if it has already been used uses times within window. 0 means it was allowed.
+	Take(key string, uses int, window time.Duration) (time.Duration, error)
+	// Refund gives back the latest use taken for key when the command didn't run
+	Refund(key string) error
+}
+
+// MemoryCooldownStore the default CooldownStore which keeps the cooldowns in memory.
+// This should be created with NewMemoryCooldownStore.
+type MemoryCooldownStore struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	cooldowns map[string]*memoryCooldown
+	lastSweep time.Time
+}
+
+type memoryCooldown struct {
+	used   []time.Time
+	window time.Duration
+}
+
+// memoryCooldownSweep how often expired cooldowns are removed
+const memoryCooldownSweep = time.Minute
+
+// NewMemoryCooldownStore an empty in memory store
+func NewMemoryCooldownStore() *MemoryCooldownStore {
+	return &MemoryCooldownStore{
+		now:       time.Now,
+		cooldowns: make(map[string]*memoryCooldown),
+	}
+}
+
+func (m *MemoryCooldownStore) Take(key string, uses int, window time.Duration) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) > memoryCooldownSweep {
+		m.sweep(now)
+	}
+
+	cooldown, ok := m.cooldowns[key]
+	if !ok {
+		cooldown = &memoryCooldown{}
+		m.cooldowns[key] = cooldown
+	}
+	cooldown.window = window
+
+	// Only keep the uses still inside of the window
+	recent := cooldown.used[:0]
+	for _, used := range cooldown.used {
+		if now.Sub(used) < window {
+			recent = append(recent, used)
+		}
+	}
+	cooldown.used = recent
+
+	if len(recent) >= uses {
+		return recent[len(recent)-uses].Add(window).Sub(now), nil
+	}
+
+	cooldown.used = append(cooldown.used, now)
+	return 0, nil
+}
+
+func (m *MemoryCooldownStore) Refund(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cooldown, ok := m.cooldowns[key]; ok && len(cooldown.used) > 0 {
+		cooldown.used = cooldown.used[:len(cooldown.used)-1]
+	}
+
+	return nil
+}
+
+// sweep removes cooldowns whose uses have all expired
+func (m *MemoryCooldownStore) sweep(now time.Time) {
+	for key, cooldown := range m.cooldowns {
+		if len(cooldown.used) == 0 || now.Sub(cooldown.used[len(cooldown.used)-1]) >= cooldown.window {
+			delete(m.cooldowns, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+func (c *Cooldown) valid() error {
+	if c.Uses < 1 || c.Window <= 0 {
+		return fmt.Errorf("invalid cooldown must have at least one use and a window")
+	}
+
+	return nil
+}
+
+// key the key of the cooldown for the command where it was used
+func (c *Cooldown) key(path string, payload *InteractionPayload) string {
+	switch c.Bucket {
+	case CooldownChannel:
+		return path + "/channel/" + payload.ChannelId
+	case CooldownGuild:
+		if payload.GuildId == "" {
+			return path + "/channel/" + payload.ChannelId
+		}
+		return path + "/guild/" + payload.GuildId
+	case CooldownGlobal:
+		return path + "/global"
+	}
+
+	return path + "/user/" + payload.AuthorId
+}
+
+func (c *Cooldown) bypassed(s *discordgo.Session, inter Interaction) bool {
+	for _, check := range c.Bypass {
+		if check(s, inter) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// takeCooldowns uses the cooldowns of each command from the root down to cmd.
+// If any of them is on cooldown the uses already taken are refunded so a command
+// which doesn't run doesn't use up its parent's cooldown.
+func (cs *CommandSet) takeCooldowns(s *discordgo.Session, root, cmd *Command, inter Interaction) error {
+	var names, taken []string
+	refund := func() {
+		for _, key := range taken {
+			cs.CooldownStore.Refund(key)
+		}
+	}
+
+	for _, com := range root.pathTo(cmd) {
+		names = append(names, com.Name)
+		cooldown := com.Cooldown
+		if cooldown == nil || cooldown.bypassed(s, inter) {
+			continue
+		}
+
+		path := strings.Join(names, " ")
+		key := cooldown.key(path, inter.GetPayload())
+		retryAfter, err := cs.CooldownStore.Take(key, cooldown.Uses, cooldown.Window)
+		if err != nil {
+			refund()
+			return errors.Wrapf(err, "unable to take cooldown for %s", path)
+		}
+
+		if retryAfter > 0 {
+			refund()
+			return &CooldownError{Command: path, RetryAfter: retryAfter}
+		}
+		taken = append(taken, key)
+	}
+
+	return nil
+}
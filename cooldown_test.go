@@ -0,0 +1,193 @@
+package discom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCooldownStore(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryCooldownStore()
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		retryAfter, err := store.Take("key", 2, time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), retryAfter)
+		now = now.Add(10 * time.Second)
+	}
+
+	retryAfter, _ := store.Take("key", 2, time.Minute)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	retryAfter, _ = store.Take("other", 2, time.Minute)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	// The first use has left the window
+	now = now.Add(40 * time.Second)
+	retryAfter, _ = store.Take("key", 2, time.Minute)
+	assert.Equal(t, time.Duration(0), retryAfter)
+	retryAfter, _ = store.Take("key", 2, time.Minute)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	// Refunding gives the use back
+	assert.NoError(t, store.Refund("other"))
+	retryAfter, _ = store.Take("other", 1, time.Minute)
+	assert.Equal(t, time.Duration(0), retryAfter)
+
+	// Expired cooldowns are swept
+	now = now.Add(2 * time.Minute)
+	store.Take("new", 1, time.Minute)
+	assert.Len(t, store.cooldowns, 1)
+}
+
+func TestCooldowns(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	now := time.Unix(0, 0)
+	store := NewMemoryCooldownStore()
+	store.now = func() time.Time { return now }
+	cs.CooldownStore = store
+
+	ran := 0
+	handler := func(*discordgo.Session, Interaction) error {
+		ran++
+		return nil
+	}
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:    "expensive",
+		Handler: handler,
+		Cooldown: &Cooldown{
+			Uses:   1,
+			Window: time.Minute,
+			Bucket: CooldownUser,
+			Bypass: []Check{IsUser("ownerID")},
+		},
+	}))
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:     "shared",
+		Handler:  handler,
+		Cooldown: &Cooldown{Uses: 1, Window: time.Minute, Bucket: CooldownGuild},
+	}))
+	assert.Error(t, cs.AddCommand(Command{
+		Name:     "broken",
+		Handler:  handler,
+		Cooldown: &Cooldown{Window: time.Minute},
+	}))
+
+	s, _ := newTestSession()
+
+	prefix := func(authorID, content string) error {
+		errored = nil
+		cs.Handler(s, &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				GuildID:   "guildID",
+				ChannelID: "channelID",
+				Author:    &discordgo.User{ID: authorID},
+				Content:   content,
+			},
+		})
+		return errored
+	}
+
+	slash := func(authorID, name string) error {
+		errored = nil
+		cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:        "interactionID",
+				Token:     "token",
+				Type:      discordgo.InteractionApplicationCommand,
+				GuildID:   "guildID",
+				ChannelID: "channelID",
+				Member:    &discordgo.Member{User: &discordgo.User{ID: authorID}},
+				Data:      discordgo.ApplicationCommandInteractionData{Name: name},
+			},
+		})
+		return errored
+	}
+
+	// Prefix and slash commands share the cooldown
+	assert.NoError(t, prefix("userID", "test$ expensive"))
+	now = now.Add(15 * time.Second)
+	err := slash("userID", "expensive")
+	var cooldownErr *CooldownError
+	assert.True(t, errors.As(err, &cooldownErr))
+	assert.Equal(t, 45*time.Second, cooldownErr.RetryAfter)
+	assert.True(t, errors.Is(err, ErrCooldown))
+	assert.EqualError(t, err, "expensive is on cooldown try again in 45s")
+	assert.Equal(t, 1, ran)
+
+	// Other users have their own cooldown and the owner bypasses it
+	assert.NoError(t, slash("otherID", "expensive"))
+	assert.NoError(t, prefix("ownerID", "test$ expensive"))
+	assert.NoError(t, prefix("ownerID", "test$ expensive"))
+	assert.Equal(t, 4, ran)
+
+	now = now.Add(45 * time.Second)
+	assert.NoError(t, prefix("userID", "test$ expensive"))
+
+	// Guild cooldowns are shared by everyone in the guild
+	assert.NoError(t, prefix("userID", "test$ shared"))
+	assert.True(t, errors.Is(slash("otherID", "shared"), ErrCooldown))
+}
+
+func TestSubCommandCooldowns(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	ran := 0
+	handler := func(*discordgo.Session, Interaction) error {
+		ran++
+		return nil
+	}
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:     "db",
+		Cooldown: &Cooldown{Uses: 2, Window: time.Minute},
+		SubCommands: []Command{
+			{Name: "backup", Handler: handler, Cooldown: &Cooldown{Uses: 1, Window: time.Minute}},
+			{Name: "status", Handler: handler},
+		},
+	}))
+
+	s, _ := newTestSession()
+	prefix := func(content string) error {
+		errored = nil
+		cs.Handler(s, &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				ChannelID: "channelID",
+				Author:    &discordgo.User{ID: "userID"},
+				Content:   content,
+			},
+		})
+		return errored
+	}
+
+	assert.NoError(t, prefix("test$ db backup"))
+
+	// The sub command is on cooldown which mustn't use up the parent's cooldown
+	for i := 0; i < 3; i++ {
+		var cooldownErr *CooldownError
+		assert.True(t, errors.As(prefix("test$ db backup"), &cooldownErr))
+		assert.Equal(t, "db backup", cooldownErr.Command)
+	}
+
+	assert.NoError(t, prefix("test$ db status"))
+	assert.Equal(t, 2, ran)
+
+	// Both uses of the parent have now been spent
+	var cooldownErr *CooldownError
+	assert.True(t, errors.As(prefix("test$ db status"), &cooldownErr))
+	assert.Equal(t, "db", cooldownErr.Command)
+	assert.Equal(t, 2, ran)
+}
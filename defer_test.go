@@ -0,0 +1,71 @@
+package discom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoDefer(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "slow",
+		Description: "takes a while",
+		AutoDefer:   10 * time.Millisecond,
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			time.Sleep(50 * time.Millisecond)
+			return i.Respond(s, Response{Content: "done"})
+		},
+	}))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "fast",
+		Description: "responds straight away",
+		AutoDefer:   time.Second,
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			return i.Respond(s, Response{Content: "done"})
+		},
+	}))
+
+	s, transport := newTestSession()
+	slash := func(name string) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:    "interactionID",
+				AppID: "appID",
+				Token: "token",
+				Type:  discordgo.InteractionApplicationCommand,
+				Data:  discordgo.ApplicationCommandInteractionData{Name: name},
+			},
+		}
+	}
+
+	cs.IntreactionHandler(s, slash("slow"))
+	requests := transport.Requests()
+	assert.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Body, `"type":5`)
+	assert.Equal(t, "PATCH", requests[1].Method)
+	assert.Contains(t, requests[1].Path, "/messages/@original")
+	assert.Contains(t, requests[1].Body, `"content":"done"`)
+
+	cs.IntreactionHandler(s, slash("fast"))
+	requests = transport.Requests()[2:]
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, `"type":4`)
+
+	// Prefix commands show the typing indicator
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ slow",
+		},
+	})
+	requests = transport.Requests()[3:]
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "/api/v9/channels/channelID/typing", requests[0].Path)
+	assert.Equal(t, "/api/v9/channels/channelID/messages", requests[1].Path)
+}
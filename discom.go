@@ -1,12 +1,12 @@
 package discom
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -16,10 +16,29 @@ import (
 var (
 	ErrTooManyArgs = fmt.Errorf("more args given then options")
 	ErrInvalidArg  = fmt.Errorf("invalid arg")
+	// ErrUnknownSubCommand a prefix command was given without one of its sub commands
+	ErrUnknownSubCommand = fmt.Errorf("unknown sub command")
 )
 
+// DefaultAutoDefer a safe duration for Command.AutoDefer within discord's 3 second limit
+const DefaultAutoDefer = 2 * time.Second
+
+// Response what is sent back to the user.
+// When editing an earlier response Embeds and Components are only replaced if they are not nil.
 type Response struct {
 	Content string
+	// Ephemeral only the user who invoked the command can see the response.
+	// This can only be set on the first response. Editing a public response with it set
+	// returns ErrEphemeralAfterPublic so use Command.EphemeralDefer if the command may be deferred.
+	// Prefix commands use CommandSet.EphemeralFallback instead.
+	Ephemeral bool
+	Embeds    []*discordgo.MessageEmbed
+	// Files uploaded with the response when editing they are added to the existing files
+	Files []*discordgo.File
+	// Components rows of buttons and select menus handle them with CommandSet.AddComponent
+	Components      []discordgo.MessageComponent
+	TTS             bool
+	AllowedMentions *discordgo.MessageAllowedMentions
 }
 
 type InteractionPayload struct {
@@ -27,13 +46,32 @@ type InteractionPayload struct {
 	AuthorId  string
 	GuildId   string
 	ChannelId string
+	// AuthorRoleIds the roles of the author empty outside of guilds
+	AuthorRoleIds []string
 }
 
 // Interaction any interfaction with the commands
 type Interaction interface {
+	// Respond sends the first response later calls edit it
 	Respond(*discordgo.Session, Response) error
 	GetPayload() *InteractionPayload
 	Option(name string) *discordgo.ApplicationCommandInteractionDataOption
+	// Attachment the file given for an attachment option nil if there isn't one
+	Attachment(name string) *discordgo.MessageAttachment
+	// Target what a user or message command was used on nil for other commands
+	Target() *Target
+	// RespondModal shows a modal to the user this must be the first response
+	RespondModal(*discordgo.Session, Modal) error
+	// Defer acknowledges the interaction so the handler can take longer than discord's 3 second limit.
+	// Later calls to Respond edit the deferred response.
+	// For prefix commands this shows the typing indicator.
+	Defer(*discordgo.Session) error
+	// Followup sends a new message instead of editing the first response like Respond.
+	// If nothing has been sent yet this is the same as Respond.
+	Followup(*discordgo.Session, Response) (FollowupMessage, error)
+	// Context cancelled when the command's Timeout passes or the command set is shut down.
+	// It holds the Invocation see InvocationFromContext.
+	Context() context.Context
 }
 
 // CommandHandler A callback function which is triggered when a command is ran
@@ -45,100 +83,319 @@ type ErrorHandler func(*discordgo.Session, Interaction, error)
 
 // Command Represents a Command to the discord bot.
 type Command struct {
-	// Name the name of the command commands should not have spaces
+	// Name the name of the command chat commands should not have spaces
 	Name string
+	// Type the kind of command defaults to a chat command.
+	// User and message commands are only available from the context menu not by prefix.
+	Type discordgo.ApplicationCommandType
 	// Handler The handler function which is called on a message matching the regex
 	Handler     CommandHandler
 	Description string
 	Version     string
 	Options     []*discordgo.ApplicationCommandOption
+	// NameLocalizations and DescriptionLocalizations the name and description in other languages
+	NameLocalizations        map[discordgo.Locale]string
+	DescriptionLocalizations map[discordgo.Locale]string
+	// DefaultMemberPermissions the permissions a member needs to use the command by default nil means everyone
+	DefaultMemberPermissions *int64
+	// DMPermission whether the global command can be used in DMs nil means it can.
+	// This and DefaultMemberPermissions and NSFW are also checked for prefix commands.
+	DMPermission *bool
+	// NSFW whether the command is age restricted
+	NSFW bool
+	// Checks must all pass for the command to run they run after CommandSet.Checks.
+	// The checks of a parent command also apply to its sub commands.
+	Checks []Check
+	// Cooldown if set limits how often the command can be used.
+	// The cooldown of a parent command is shared by its sub commands.
+	Cooldown *Cooldown
+	// Timeout if set the interaction's context is cancelled after this long
+	Timeout time.Duration
+	// Middleware wraps the handler inside of the middleware added with CommandSet.Use.
+	// The middleware of a parent command also wraps its sub commands.
+	Middleware []Middleware
+	// Contexts where the command can be used nil is discord's default
+	Contexts []InteractionContextType
+	// AutoDefer if set the interaction is deferred when the handler hasn't responded within this duration.
+	// DefaultAutoDefer leaves enough time for the defer to reach discord.
+	AutoDefer time.Duration
+	// EphemeralDefer deferring shows the thinking message only to the user
+	// so the response can be Ephemeral. The response is always ephemeral once deferred.
+	EphemeralDefer bool
+	// Overflow what to do when a response is too long for one message
+	Overflow Overflow
+	// GuildIDs the guilds the command is registered in instead of globally.
+	// If empty CommandSet.GuildIDs is used.
+	GuildIDs []string
+	// Autocomplete called for options which have Autocomplete set
+	Autocomplete AutocompleteHandler
+	// SubCommands children of the command a command with sub commands cannot have options or a handler.
+	// A sub command which has its own sub commands is a sub command group.
+	SubCommands []Command
+}
+
+func (c *Command) asAppCommand() *AppCommand {
+	return &AppCommand{
+		Type:                     c.Type,
+		Name:                     c.Name,
+		NameLocalizations:        c.NameLocalizations,
+		Description:              c.Description,
+		DescriptionLocalizations: c.DescriptionLocalizations,
+		Version:                  c.Version,
+		Options:                  c.appCommandOptions(),
+		DefaultMemberPermissions: c.DefaultMemberPermissions,
+		DMPermission:             c.DMPermission,
+		NSFW:                     c.NSFW,
+		Contexts:                 c.Contexts,
+	}
+}
+
+// chatInput reports whether the command is typed by the user rather than picked from a context menu
+func (c *Command) chatInput() bool {
+	return c.Type == 0 || c.Type == discordgo.ChatApplicationCommand
+}
+
+func (c *Command) key() string {
+	return CommandKey(c.Type, c.Name)
 }
 
-func (c *Command) asDiscordAppCommand() *discordgo.ApplicationCommand {
-	return &discordgo.ApplicationCommand{
-		Name:        c.Name,
-		Description: c.Description,
-		Version:     c.Version,
-		Options:     c.Options,
+// appCommandOptions the options of the command with sub commands nested as discord expects
+func (c *Command) appCommandOptions() []*discordgo.ApplicationCommandOption {
+	if len(c.SubCommands) == 0 {
+		return c.Options
+	}
+
+	var result []*discordgo.ApplicationCommandOption
+	for _, sub := range c.SubCommands {
+		optionType := discordgo.ApplicationCommandOptionSubCommand
+		if len(sub.SubCommands) > 0 {
+			optionType = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+
+		result = append(result, &discordgo.ApplicationCommandOption{
+			Type:                     optionType,
+			Name:                     sub.Name,
+			NameLocalizations:        sub.NameLocalizations,
+			Description:              sub.Description,
+			DescriptionLocalizations: sub.DescriptionLocalizations,
+			Options:                  sub.appCommandOptions(),
+		})
 	}
+
+	return result
 }
 
-func (c *Command) parseArgs(args []string) ([]*discordgo.ApplicationCommandInteractionDataOption, error) {
+// findSubCommand walks the sub command options sent by discord returning the command to run and its options
+func (c *Command) findSubCommand(options []*discordgo.ApplicationCommandInteractionDataOption) (*Command, []*discordgo.ApplicationCommandInteractionDataOption) {
+	if len(c.SubCommands) == 0 {
+		return c, options
+	}
+
+	for _, option := range options {
+		if option.Type != discordgo.ApplicationCommandOptionSubCommand &&
+			option.Type != discordgo.ApplicationCommandOptionSubCommandGroup {
+			continue
+		}
+
+		for i := range c.SubCommands {
+			if c.SubCommands[i].Name == option.Name {
+				return c.SubCommands[i].findSubCommand(option.Options)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// findSubCommandArgs walks the prefix arguments returning the command to run and its remaining arguments
+func (c *Command) findSubCommandArgs(args []string) (*Command, []string, error) {
+	if len(c.SubCommands) == 0 {
+		return c, args, nil
+	}
+
+	names := make([]string, len(c.SubCommands))
+	for i := range c.SubCommands {
+		if len(args) > 0 && c.SubCommands[i].Name == args[0] {
+			return c.SubCommands[i].findSubCommandArgs(args[1:])
+		}
+		names[i] = c.SubCommands[i].Name
+	}
+
 	if len(args) == 0 {
-		return nil, nil
+		return nil, nil, errors.Wrapf(ErrUnknownSubCommand, "%s expects one of %s", c.Name, strings.Join(names, ", "))
+	}
+
+	return nil, nil, errors.Wrapf(ErrUnknownSubCommand, "%s is not one of %s", args[0], strings.Join(names, ", "))
+}
+
+// isFlag reports whether arg names one of the options with the - prefix.
+// Anything else starting with - such as a negative number is treated as a value.
+func isFlag(arg string, optionsMap map[string]*discordgo.ApplicationCommandOption) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
 	}
 
-	if len(args)%2 != 0 {
-		return nil, errors.Wrapf(ErrInvalidArg, "argument missing value")
+	if _, ok := optionsMap[strings.TrimPrefix(arg, "-")]; ok {
+		return true
 	}
 
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+// argContext what is needed to resolve arguments which refer to discord objects
+type argContext struct {
+	session *discordgo.Session
+	message *discordgo.Message
+}
+
+// parseArgs converts prefix command arguments into options.
+// Arguments are either named "-name value" pairs or positional values which are
+// bound to the options not already named in the order they are declared.
+func (c *Command) parseArgs(ctx argContext, args []string) ([]*discordgo.ApplicationCommandInteractionDataOption, error) {
 	argMap := make(map[string]string)
 
 	optionsMap := make(map[string]*discordgo.ApplicationCommandOption)
-	requiredCount := 0
 	for _, option := range c.Options {
 		optionsMap[option.Name] = option
-		if option.Required {
-			requiredCount++
-		}
 	}
 
-	for i := 0; i < len(args); i += 2 {
-		cmd := args[i]
-		if !strings.HasPrefix(cmd, "-") {
-			return nil, errors.Wrapf(ErrInvalidArg, "%s must have prefix -", cmd)
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		if !isFlag(args[i], optionsMap) {
+			positional = append(positional, args[i])
+			continue
 		}
 
-		cmd = strings.TrimPrefix(cmd, "-")
-
+		cmd := strings.TrimPrefix(args[i], "-")
 		if _, ok := optionsMap[cmd]; !ok {
 			return nil, errors.Wrapf(ErrInvalidArg, "%s is an unknown argument", cmd)
 		}
 
-		if optionsMap[cmd].Required {
-			requiredCount--
+		if optionsMap[cmd].Type == discordgo.ApplicationCommandOptionAttachment {
+			return nil, errors.Wrapf(ErrInvalidArg, "%s must be given as a file attached to the message", cmd)
+		}
+
+		if _, ok := argMap[cmd]; ok {
+			return nil, errors.Wrapf(ErrInvalidArg, "%s given more than once", cmd)
 		}
 
-		argMap[cmd] = args[i+1]
+		if i+1 >= len(args) {
+			return nil, errors.Wrapf(ErrInvalidArg, "%s missing value", cmd)
+		}
+
+		i++
+		argMap[cmd] = args[i]
+	}
+
+	for _, option := range c.Options {
+		if len(positional) == 0 {
+			break
+		}
+
+		if _, ok := argMap[option.Name]; ok || option.Type == discordgo.ApplicationCommandOptionAttachment {
+			continue
+		}
+
+		argMap[option.Name] = positional[0]
+		positional = positional[1:]
+	}
+
+	if len(positional) > 0 {
+		return nil, errors.Wrapf(ErrTooManyArgs, "unexpected %s", strings.Join(positional, " "))
+	}
+
+	// Files attached to the message are bound to attachment options in order,
+	// any extra files such as a screenshot sent with the command are ignored
+	if ctx.message != nil {
+		attachments := ctx.message.Attachments
+		for _, option := range c.Options {
+			if option.Type != discordgo.ApplicationCommandOptionAttachment || len(attachments) == 0 {
+				continue
+			}
+
+			argMap[option.Name] = attachments[0].ID
+			attachments = attachments[1:]
+		}
 	}
 
-	if requiredCount != 0 {
-		return nil, errors.Wrapf(ErrInvalidArg, "missing required argument")
+	for _, option := range c.Options {
+		if _, ok := argMap[option.Name]; option.Required && !ok {
+			return nil, errors.Wrapf(ErrInvalidArg, "missing required argument %s", option.Name)
+		}
 	}
 
 	var result []*discordgo.ApplicationCommandInteractionDataOption
 
-	for cmd, arg := range argMap {
+	for _, option := range c.Options {
+		arg, ok := argMap[option.Name]
+		if !ok {
+			continue
+		}
+
 		var value interface{}
 		var err error
-		switch optionsMap[cmd].Type {
-		case discordgo.ApplicationCommandOptionString:
-			value = arg
-		case discordgo.ApplicationCommandOptionInteger:
-			intVal, err := strconv.ParseInt(arg, 10, 64)
-			if err != nil {
-				return nil, fmt.Errorf("expected %s to be an int but was given %s", optionsMap[cmd].Name, arg)
-			}
-			value = float64(intVal)
-		case discordgo.ApplicationCommandOptionBoolean:
-			value, err = strconv.ParseBool(arg)
-			if err != nil {
-				return nil, fmt.Errorf("expected %s to be an bool but was given %s", optionsMap[cmd].Name, arg)
-			}
-		default:
-			return nil, fmt.Errorf("not implemnted yet")
+		if len(option.Choices) > 0 {
+			value, err = ctx.matchChoice(option, arg)
+		} else {
+			value, err = ctx.parseOptionValue(option, arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkConstraints(option, value); err != nil {
+			return nil, err
 		}
 
 		result = append(result, &discordgo.ApplicationCommandInteractionDataOption{
-			Name:  optionsMap[cmd].Name,
+			Name:  option.Name,
 			Value: value,
-			Type:  optionsMap[cmd].Type,
+			Type:  option.Type,
 		})
 	}
 
 	return result, nil
 }
 
+// parseOptionValue converts a single prefix argument into the value discord would send for the option
+func (ctx argContext) parseOptionValue(option *discordgo.ApplicationCommandOption, arg string) (interface{}, error) {
+	switch option.Type {
+	case discordgo.ApplicationCommandOptionString:
+		return arg, nil
+	case discordgo.ApplicationCommandOptionInteger:
+		intVal, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected %s to be an int but was given %s", option.Name, arg)
+		}
+		return float64(intVal), nil
+	case discordgo.ApplicationCommandOptionBoolean:
+		value, err := strconv.ParseBool(arg)
+		if err != nil {
+			return nil, fmt.Errorf("expected %s to be an bool but was given %s", option.Name, arg)
+		}
+		return value, nil
+	case discordgo.ApplicationCommandOptionNumber:
+		value, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected %s to be a number but was given %s", option.Name, arg)
+		}
+		return value, nil
+	case discordgo.ApplicationCommandOptionAttachment:
+		return arg, nil
+	case discordgo.ApplicationCommandOptionUser:
+		return ctx.parseUser(option, arg)
+	case discordgo.ApplicationCommandOptionChannel:
+		return ctx.parseChannel(option, arg)
+	case discordgo.ApplicationCommandOptionRole:
+		return ctx.parseRole(option, arg)
+	case discordgo.ApplicationCommandOptionMentionable:
+		return ctx.parseMentionable(option, arg)
+	}
+
+	return nil, fmt.Errorf("not implemnted yet")
+}
+
 func genOptionsMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
 	result := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
 	for _, option := range options {
@@ -149,30 +406,63 @@ func genOptionsMap(options []*discordgo.ApplicationCommandInteractionDataOption)
 }
 
 type discordInteraction struct {
+	// mu guards against the auto defer timer responding at the same time as the handler
+	mu          sync.Mutex
 	sent        bool
+	overflow    Overflow
 	interaction *discordgo.Interaction
-	optionsMap  map[string]*discordgo.ApplicationCommandInteractionDataOption
+	ctx         context.Context
+	// ephemeralDefer defer with a response only the user can see
+	ephemeralDefer bool
+	// public the first response can be seen by everyone
+	public bool
+	// deferred how the interaction was deferred 0 if it wasn't
+	deferred discordgo.InteractionResponseType
+	// options the options of the sub command which was invoked
+	options    []*discordgo.ApplicationCommandInteractionDataOption
+	optionsMap map[string]*discordgo.ApplicationCommandInteractionDataOption
 }
 
 func (d *discordInteraction) Option(name string) *discordgo.ApplicationCommandInteractionDataOption {
 	if d.optionsMap == nil {
-		d.optionsMap = genOptionsMap(d.interaction.ApplicationCommandData().Options)
+		d.optionsMap = genOptionsMap(d.options)
 	}
 
 	return d.optionsMap[name]
 }
 
+func (d *discordInteraction) Attachment(name string) *discordgo.MessageAttachment {
+	option := d.Option(name)
+	if option == nil || option.Type != discordgo.ApplicationCommandOptionAttachment {
+		return nil
+	}
+
+	resolved := d.interaction.ApplicationCommandData().Resolved
+	if resolved == nil {
+		return nil
+	}
+
+	return resolved.Attachments[option.Value.(string)]
+}
+
 func (d *discordInteraction) Options() []*discordgo.ApplicationCommandInteractionDataOption {
-	return d.interaction.ApplicationCommandData().Options
+	return d.options
 }
 
 func (d *discordInteraction) GetPayload() *InteractionPayload {
 	result := &InteractionPayload{
-		AuthorId:  d.interaction.Member.User.ID,
 		GuildId:   d.interaction.GuildID,
 		ChannelId: d.interaction.ChannelID,
 	}
 
+	// Member is only set in guilds and User only in DMs
+	if d.interaction.Member != nil {
+		result.AuthorId = d.interaction.Member.User.ID
+		result.AuthorRoleIds = d.interaction.Member.Roles
+	} else if d.interaction.User != nil {
+		result.AuthorId = d.interaction.User.ID
+	}
+
 	if d.interaction.Message != nil {
 		result.Message = d.interaction.Message.Content
 	}
@@ -181,36 +471,151 @@ func (d *discordInteraction) GetPayload() *InteractionPayload {
 }
 
 func (d *discordInteraction) Respond(s *discordgo.Session, res Response) error {
-	body := res.Content
-	if len(res.Content) >= 2000 {
-		left, right := body[0:1999], body[2000:len(body)-1]
-		defer d.Respond(s, Response{Content: right})
-		body = left
-	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.respond(s, res)
+}
+
+// respond content too long for one message is sent as follow up messages
+func (d *discordInteraction) respond(s *discordgo.Session, res Response) error {
+	res = applyOverflow(res, d.overflow)
+	chunks := splitMessage(res.Content, maxMessageLength)
+	res.Content = chunks[0]
 
 	if !d.sent {
 		err := s.InteractionRespond(d.interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: body,
-			},
+			Data: res.interactionResponseData(),
 		})
-		d.sent = err == nil
+		if err != nil {
+			return err
+		}
+		d.sent = true
+		d.public = !res.Ephemeral
+	} else if res.Ephemeral && d.public {
+		return ErrEphemeralAfterPublic
+	} else if _, err := s.InteractionResponseEdit(d.interaction, res.webhookEdit()); err != nil {
 		return err
 	}
 
-	_, err := s.InteractionResponseEdit(d.interaction, &discordgo.WebhookEdit{
-		Content: &body,
-	})
+	return d.followupChunks(s, res, chunks[1:])
+}
 
+// followupChunks sends the rest of a split response in order
+func (d *discordInteraction) followupChunks(s *discordgo.Session, res Response, chunks []string) error {
+	for _, chunk := range chunks {
+		next := res.continuation(chunk)
+		if _, err := s.FollowupMessageCreate(d.interaction, true, next.webhookParams()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *discordInteraction) Defer(s *discordgo.Session) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sent {
+		return nil
+	}
+
+	res := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}
+	if d.ephemeralDefer {
+		res.Data = &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+	}
+
+	if err := s.InteractionRespond(d.interaction, res); err != nil {
+		return err
+	}
+
+	d.sent = true
+	d.public = !d.ephemeralDefer
+	d.deferred = res.Type
+	return nil
+}
+
+func (d *discordInteraction) RespondModal(s *discordgo.Session, modal Modal) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sent {
+		return fmt.Errorf("a modal must be the first response")
+	}
+
+	err := s.InteractionRespond(d.interaction, modal.asInteractionResponse())
+	d.sent = err == nil
+	return err
+}
+
+// update replaces the message a component is attached to
+func (d *discordInteraction) update(s *discordgo.Session, res Response) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Defer responded with a new message so editing the response wouldn't change the component's message
+	if d.deferred == discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		return ErrUpdateAfterDefer
+	}
+
+	res, err := fitOverflow(res, d.overflow)
+	if err != nil {
+		return err
+	}
+
+	if d.sent {
+		_, err = s.InteractionResponseEdit(d.interaction, res.webhookEdit())
+		return err
+	}
+
+	err = s.InteractionRespond(d.interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: res.interactionResponseData(),
+	})
+	d.sent = err == nil
+	// The first response is now the message which was updated
+	d.public = d.interaction.Message == nil || d.interaction.Message.Flags&discordgo.MessageFlagsEphemeral == 0
 	return err
 }
 
+// deferUpdate acknowledges a component or modal so the message it came from can be updated later
+func (d *discordInteraction) deferUpdate(s *discordgo.Session) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sent {
+		return nil
+	}
+
+	err := s.InteractionRespond(d.interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		return err
+	}
+
+	d.sent = true
+	d.public = d.interaction.Message == nil || d.interaction.Message.Flags&discordgo.MessageFlagsEphemeral == 0
+	d.deferred = discordgo.InteractionResponseDeferredMessageUpdate
+	return nil
+}
+
 type discordMessage struct {
-	message    *discordgo.Message
-	sentId     string
-	options    []*discordgo.ApplicationCommandInteractionDataOption
-	optionsMap map[string]*discordgo.ApplicationCommandInteractionDataOption
+	// mu guards against the auto defer timer responding at the same time as the handler
+	mu       sync.Mutex
+	cs       *CommandSet
+	overflow Overflow
+	message  *discordgo.Message
+	ctx      context.Context
+	sentId   string
+	// sentChannelId differs from the message's channel if the response was sent as a DM
+	sentChannelId string
+	options       []*discordgo.ApplicationCommandInteractionDataOption
+	optionsMap    map[string]*discordgo.ApplicationCommandInteractionDataOption
 }
 
 func (d *discordMessage) Option(name string) *discordgo.ApplicationCommandInteractionDataOption {
@@ -221,33 +626,110 @@ func (d *discordMessage) Option(name string) *discordgo.ApplicationCommandIntera
 	return d.optionsMap[name]
 }
 
+func (d *discordMessage) Attachment(name string) *discordgo.MessageAttachment {
+	option := d.Option(name)
+	if option == nil || option.Type != discordgo.ApplicationCommandOptionAttachment {
+		return nil
+	}
+
+	for _, attachment := range d.message.Attachments {
+		if attachment.ID == option.Value.(string) {
+			return attachment
+		}
+	}
+
+	return nil
+}
+
+func (d *discordMessage) Defer(s *discordgo.Session) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sentId != "" {
+		return nil
+	}
+
+	return s.ChannelTyping(d.message.ChannelID)
+}
+
+func (d *discordMessage) RespondModal(*discordgo.Session, Modal) error {
+	return ErrModalUnsupported
+}
+
 func (d *discordMessage) GetPayload() *InteractionPayload {
-	return &InteractionPayload{
+	result := &InteractionPayload{
 		Message:   d.message.Content,
 		AuthorId:  d.message.Author.ID,
 		GuildId:   d.message.GuildID,
 		ChannelId: d.message.ChannelID,
 	}
+
+	if d.message.Member != nil {
+		result.AuthorRoleIds = d.message.Member.Roles
+	}
+
+	return result
 }
 
 func (d *discordMessage) Respond(s *discordgo.Session, res Response) error {
-	body := res.Content
-	if len(res.Content) >= 2000 {
-		left, right := body[0:1999], body[2000:len(body)-1]
-		defer d.Respond(s, Response{Content: right})
-		body = left
-	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.respond(s, res)
+}
+
+// respond content too long for one message is sent as more messages
+func (d *discordMessage) respond(s *discordgo.Session, res Response) error {
+	res = applyOverflow(res, d.overflow)
+	chunks := splitMessage(res.Content, maxMessageLength)
+	res.Content = chunks[0]
 
 	if d.sentId == "" {
-		msg, err := s.ChannelMessageSend(d.message.ChannelID, body)
-		if err == nil {
-			d.sentId = msg.ID
+		msg, err := d.send(s, res)
+		if err != nil {
+			return err
 		}
+
+		d.sentId, d.sentChannelId = msg.id, msg.channelID
+	} else if _, err := s.ChannelMessageEditComplex(res.messageEdit(d.sentChannelId, d.sentId)); err != nil {
 		return err
 	}
 
-	_, err := s.ChannelMessageEdit(d.message.ChannelID, d.sentId, body)
-	return err
+	return d.sendChunks(s, res, chunks[1:])
+}
+
+// send sends a new message to the channel or wherever ephemeral responses go
+func (d *discordMessage) send(s *discordgo.Session, res Response) (*channelMessage, error) {
+	channelID := d.message.ChannelID
+	if res.Ephemeral {
+		var err error
+		channelID, err = d.ephemeralChannel(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msg, err := s.ChannelMessageSendComplex(channelID, res.messageSend())
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Ephemeral {
+		d.scheduleEphemeralDelete(s, channelID, msg.ID)
+	}
+
+	return &channelMessage{channelID: channelID, id: msg.ID, overflow: d.overflow}, nil
+}
+
+// sendChunks sends the rest of a split response in order
+func (d *discordMessage) sendChunks(s *discordgo.Session, res Response, chunks []string) error {
+	for _, chunk := range chunks {
+		if _, err := d.send(s, res.continuation(chunk)); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // CommandSet Use this to regsiter commands and get the handler to pass to discordgo.
@@ -255,11 +737,51 @@ func (d *discordMessage) Respond(s *discordgo.Session, res Response) error {
 type CommandSet struct {
 	Prefix       string
 	ErrorHandler ErrorHandler
-	commands     []Command
-	handlers     map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate)
+	// EphemeralFallback how ephemeral responses are sent for prefix commands
+	EphemeralFallback EphemeralFallback
+	// EphemeralDeleteAfter how long until the response is deleted for EphemeralFallbackDelete
+	EphemeralDeleteAfter time.Duration
+	// Overflow what to do when a response is too long for one message commands can override this
+	Overflow Overflow
+	// GuildIDs the guilds commands without their own GuildIDs are registered in.
+	// If empty they are registered globally.
+	GuildIDs []string
+	// Checks must all pass for any command to run
+	Checks []Check
+	// CooldownStore where command cooldowns are kept defaults to a MemoryCooldownStore
+	CooldownStore CooldownStore
+	// SyncMode how SyncAppCommands and ApplySync change the commands on discord
+	SyncMode SyncMode
+	// SyncStore if set guilds whose commands haven't changed since the last sync are skipped.
+	// This is off unless a store is set e.g. NewFileSyncStore.
+	SyncStore  SyncStore
+	commands   []Command
+	middleware []Middleware
+	components []componentRoute
+	// ctx the parent of every invocation's context cancelled by Shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	// running the invocations which haven't finished runningMu guards adding to it after Shutdown
+	runningMu sync.Mutex
+	running   sync.WaitGroup
+	modals    []modalRoute
 }
 
 func (c *Command) valid() error {
+	if !c.chatInput() {
+		return c.validContextMenu()
+	}
+
+	if strings.ToLower(c.Name) == "help" {
+		return fmt.Errorf("invalid name cannot be help")
+	}
+
+	return c.validTree(0)
+}
+
+// validTree checks the command and its sub commands.
+// discord only allows a command to have sub command groups which contain sub commands.
+func (c *Command) validTree(depth int) error {
 	if c.Name == "" {
 		return fmt.Errorf("invalid name is empty")
 	}
@@ -268,8 +790,28 @@ func (c *Command) valid() error {
 		return fmt.Errorf("invalid name conatins space")
 	}
 
-	if strings.ToLower(c.Name) == "help" {
-		return fmt.Errorf("invalid name cannot be help")
+	if c.Cooldown != nil {
+		if err := c.Cooldown.valid(); err != nil {
+			return errors.Wrapf(err, "invalid %s", c.Name)
+		}
+	}
+
+	if len(c.SubCommands) > 0 {
+		if depth >= 2 {
+			return fmt.Errorf("invalid %s sub commands can only be nested twice", c.Name)
+		}
+
+		if c.Handler != nil || c.Autocomplete != nil || len(c.Options) > 0 {
+			return fmt.Errorf("invalid %s a command with sub commands cannot have a handler or options", c.Name)
+		}
+
+		for i := range c.SubCommands {
+			if err := c.SubCommands[i].validTree(depth + 1); err != nil {
+				return errors.Wrapf(err, "invalid sub command of %s", c.Name)
+			}
+		}
+
+		return nil
 	}
 
 	if c.Handler == nil {
@@ -282,6 +824,14 @@ func (c *Command) valid() error {
 			return fmt.Errorf("all options must have a name")
 		}
 
+		if option.Autocomplete && c.Autocomplete == nil {
+			return fmt.Errorf("invalid %s has autocomplete but the command has no autocomplete handler", option.Name)
+		}
+
+		if option.Autocomplete && len(option.Choices) > 0 {
+			return fmt.Errorf("invalid %s cannot have both autocomplete and choices", option.Name)
+		}
+
 		if requiredCompleted {
 			if option.Required {
 				return fmt.Errorf("requires must be sequential")
@@ -294,17 +844,43 @@ func (c *Command) valid() error {
 	return nil
 }
 
+// validContextMenu user and message commands only have a name and handler
+func (c *Command) validContextMenu() error {
+	if c.Name == "" {
+		return fmt.Errorf("invalid name is empty")
+	}
+
+	if c.Handler == nil {
+		return fmt.Errorf("invalid handler is nil")
+	}
+
+	if c.Cooldown != nil {
+		if err := c.Cooldown.valid(); err != nil {
+			return errors.Wrapf(err, "invalid %s", c.Name)
+		}
+	}
+
+	if c.Description != "" || len(c.Options) > 0 || len(c.SubCommands) > 0 || c.Autocomplete != nil {
+		return fmt.Errorf("invalid %s context menu commands cannot have a description, options or sub commands", c.Name)
+	}
+
+	return nil
+}
+
 // CreateCommandSet Creates a command set
 func CreateCommandSet(prefix string, errorHandler ErrorHandler) (*CommandSet, error) {
 	if strings.Contains(prefix, " ") {
 		return nil, fmt.Errorf("invlaid prefix contains space")
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &CommandSet{
-		Prefix:       prefix,
-		ErrorHandler: errorHandler,
-		commands:     []Command{},
-		handlers:     make(map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate)),
+		ctx:           ctx,
+		cancel:        cancel,
+		Prefix:        prefix,
+		ErrorHandler:  errorHandler,
+		commands:      []Command{},
+		CooldownStore: NewMemoryCooldownStore(),
 	}, nil
 }
 
@@ -312,70 +888,91 @@ func cleanPattern(pattern string) string {
 	return strings.ReplaceAll(pattern, "\\", "")
 }
 
-func commandsEqual(a, b *discordgo.ApplicationCommand) bool {
-	aJson, _ := json.Marshal(a.Options)
-	bJson, _ := json.Marshal(b.Options)
-	return a.Name == b.Name && a.Description == b.Description && bytes.Equal(aJson, bJson)
-}
+// AddCommand Use this to add a command to a command set
+func (cs *CommandSet) AddCommand(com Command) error {
+	if err := com.valid(); err != nil {
+		return errors.Wrap(err, "invlaid command")
+	}
 
-func (cs *CommandSet) SyncAppCommands(s *discordgo.Session) error {
+	cs.commands = append(cs.commands, com)
 
-	commands := make(map[string]Command)
+	return nil
+}
 
-	for _, cmd := range cs.commands {
-		commands[cmd.Name] = cmd
-	}
+// findCommand the command with the type and name registered in the guild falling back
+// to the global command with the same type and name. nil if there is neither.
+func (cs *CommandSet) findCommand(cmdType discordgo.ApplicationCommandType, name, guildID string) *Command {
+	key := CommandKey(cmdType, name)
+	var global *Command
+	for idx := range cs.commands {
+		com := &cs.commands[idx]
+		if com.key() != key {
+			continue
+		}
 
-	existingCmds, _ := s.ApplicationCommands(s.State.User.ID, "")
-	// delete deleted commandss
-	for _, v := range existingCmds {
-		if _, ok := commands[v.Name]; !ok {
-			err := s.ApplicationCommandDelete(v.ApplicationID, "", v.ID)
-			if err != nil {
-				return errors.Wrapf(err, "unable to delete out of date command")
+		if len(cs.guildIDs(com)) == 0 {
+			if global == nil {
+				global = com
 			}
+		} else if guildID != "" && cs.availableIn(com, guildID) {
+			return com
 		}
 	}
 
-	// Edit updated commands
-	for _, v := range existingCmds {
-		cmd := commands[v.Name]
-		if _, ok := commands[v.Name]; ok {
-			if !commandsEqual(v, cmd.asDiscordAppCommand()) {
-				_, err := s.ApplicationCommandEdit(v.ApplicationID, "", v.ID, cmd.asDiscordAppCommand())
-				if err != nil {
-					return errors.Wrapf(err, "Cannot edit '%v' command: %v", v.Name, err)
-				}
-			}
-			delete(commands, v.Name)
-		}
+	return global
+}
+
+// handleCommand runs the slash or context menu command which was invoked
+func (cs *CommandSet) handleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	com := cs.findCommand(data.CommandType, data.Name, i.GuildID)
+	if com == nil {
+		return
 	}
 
-	// Create new commands
-	for _, cmd := range cs.commands {
-		if _, err := s.ApplicationCommandCreate(s.State.User.ID, "", cmd.asDiscordAppCommand()); err != nil {
-			log.Fatalf("Cannot create '%v' command: %v", cmd, err)
-		}
+	cmd, options := com.findSubCommand(data.Options)
+	if cmd == nil {
+		return
 	}
 
-	return nil
+	cs.runCommand(s, com, cmd, &discordInteraction{
+		sent:           false,
+		overflow:       cs.overflow(cmd),
+		interaction:    i.Interaction,
+		ephemeralDefer: cmd.EphemeralDefer,
+		options:        options,
+	})
 }
 
-// AddCommand Use this to add a command to a command set
-func (cs *CommandSet) AddCommand(com Command) error {
-	if err := com.valid(); err != nil {
-		return errors.Wrap(err, "invlaid command")
+// runCommand calls the command's handler the same way for prefix and slash commands.
+// root is the top level command cmd is the command or sub command being run.
+func (cs *CommandSet) runCommand(s *discordgo.Session, root, cmd *Command, inter invokable) {
+	done, ok := cs.begin(inter, commandPath(root, cmd), cmd.Timeout)
+	if !ok {
+		return
 	}
+	defer done()
 
-	cs.commands = append(cs.commands, com)
-	cs.handlers[com.Name] = func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		com.Handler(s, &discordInteraction{
-			sent:        false,
-			interaction: i.Interaction,
+	if cmd.AutoDefer > 0 {
+		timer := time.AfterFunc(cmd.AutoDefer, func() {
+			inter.Defer(s)
 		})
+		defer timer.Stop()
 	}
 
-	return nil
+	if err := cs.runChecks(s, root, cmd, inter); err != nil {
+		cs.ErrorHandler(s, inter, err)
+		return
+	}
+
+	if err := cs.takeCooldowns(s, root, cmd, inter); err != nil {
+		cs.ErrorHandler(s, inter, err)
+		return
+	}
+
+	if err := cs.wrapHandler(root, cmd)(s, inter); err != nil {
+		cs.ErrorHandler(s, inter, err)
+	}
 }
 
 // Handler Register this with discordgo.AddHandler will be called every time a new message is sent on a guild.
@@ -403,7 +1000,12 @@ func (cs *CommandSet) Handler(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
-	args := strings.Split(msg[1:], " ")
+	args, err := tokenize(msg)
+	if err != nil {
+		cs.ErrorHandler(s, &discordMessage{cs: cs, message: m.Message}, err)
+		return
+	}
+
 	if len(args) < 1 {
 		s.ChannelMessageSend(
 			m.ChannelID,
@@ -412,39 +1014,36 @@ func (cs *CommandSet) Handler(s *discordgo.Session, m *discordgo.MessageCreate)
 		return
 	}
 
-	for _, cmd := range cs.commands {
-		tmpMsg := args[0]
-		if tmpMsg == cmd.Name {
-
-			if len(args) > 1 {
-				//Remove command from args list
-				args = args[1:]
-			} else {
-				//Clear args
-				args = make([]string, 0)
-			}
-
-			options, err := cmd.parseArgs(args)
-			if err != nil {
-				cs.ErrorHandler(s, &discordMessage{message: m.Message}, err)
-				return
-			}
+	if com := cs.findCommand(discordgo.ChatApplicationCommand, args[0], m.GuildID); com != nil {
+		if err := checkAllowed(s, com, m.Message); err != nil {
+			cs.ErrorHandler(s, &discordMessage{cs: cs, message: m.Message}, err)
+			return
+		}
 
-			inter := &discordMessage{
-				message: m.Message,
-				options: options,
-			}
+		cmd, args, err := com.findSubCommandArgs(args[1:])
+		if err != nil {
+			cs.ErrorHandler(s, &discordMessage{cs: cs, message: m.Message}, err)
+			return
+		}
 
-			if err := cmd.Handler(s, inter); err != nil {
-				cs.ErrorHandler(s, inter, err)
-			}
+		options, err := cmd.parseArgs(argContext{session: s, message: m.Message}, args)
+		if err != nil {
+			cs.ErrorHandler(s, &discordMessage{cs: cs, message: m.Message}, err)
 			return
 		}
+
+		cs.runCommand(s, com, cmd, &discordMessage{
+			cs:       cs,
+			overflow: cs.overflow(cmd),
+			message:  m.Message,
+			options:  options,
+		})
+		return
 	}
 
 	var res string
 	if strings.ToLower(args[0]) == "help" {
-		res = cs.getHelpMessage()
+		res = cs.getHelpMessage(m.GuildID)
 	} else {
 		res = fmt.Sprintf("unknown command try \"%s help\"", cs.Prefix)
 	}
@@ -455,47 +1054,97 @@ func (cs *CommandSet) Handler(s *discordgo.Session, m *discordgo.MessageCreate)
 	)
 }
 
+// IntreactionHandler Register this with discordgo.AddHandler will be called every time a slash command or component is used.
 func (cs *CommandSet) IntreactionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if h, ok := cs.handlers[i.ApplicationCommandData().Name]; ok {
-		h(s, i)
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		cs.handleCommand(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		cs.autocomplete(s, i)
+	case discordgo.InteractionMessageComponent:
+		cs.handleComponent(s, i)
+	case discordgo.InteractionModalSubmit:
+		cs.handleModal(s, i)
 	}
 }
 
-func (cs *CommandSet) getHelpMessage() string {
+// getHelpMessage the help for the commands available in the guild
+func (cs *CommandSet) getHelpMessage(guildID string) string {
 	var result strings.Builder
 	fmt.Fprintf(&result, "here are all the commands I know\n")
-	for _, com := range cs.commands {
-		var desc string
-		if com.Description != "" {
-			desc = com.Description
-		} else {
-			desc = "missing description"
+	for idx := range cs.commands {
+		com := &cs.commands[idx]
+		// A guild's own command hides a global command with the same name
+		if com.chatInput() && cs.findCommand(com.Type, com.Name, guildID) == com {
+			cs.writeCommandHelp(&result, com.Name, com)
 		}
+	}
 
-		result.WriteString("\"")
-		result.WriteString(cleanPattern(cs.Prefix))
+	return result.String()
+}
+
+// writeCommandHelp writes the help for a command and all of its sub commands.
+// path is the full name of the command e.g. "config set".
+func (cs *CommandSet) writeCommandHelp(result *strings.Builder, path string, com *Command) {
+	var desc string
+	if com.Description != "" {
+		desc = com.Description
+	} else {
+		desc = "missing description"
+	}
+
+	result.WriteString("\"")
+	result.WriteString(cleanPattern(cs.Prefix))
+	result.WriteString(" ")
+	result.WriteString(path)
+	result.WriteString("\"")
+	result.WriteString(" ")
+	result.WriteString(desc)
+	if len(com.Options) > 0 {
+		result.WriteString(" options\n")
+	}
+
+	for _, option := range com.Options {
+		result.WriteString("\t")
+		result.WriteString(option.Name)
 		result.WriteString(" ")
-		result.WriteString(com.Name)
-		result.WriteString("\"")
+		result.WriteString(option.Description)
+		result.WriteString(" required ")
+		result.WriteString(strconv.FormatBool(option.Required))
+		result.WriteString(" type ")
+		result.WriteString(ApplicationCommandOptionToString(option.Type))
+		result.WriteString("\n")
+	}
+
+	if len(com.Options) > 0 {
+		result.WriteString("\tusage \"")
+		result.WriteString(cleanPattern(cs.Prefix))
 		result.WriteString(" ")
-		result.WriteString(desc)
-		if len(com.Options) > 0 {
-			result.WriteString(" options\n")
-		}
+		result.WriteString(com.usage(path))
+		result.WriteString("\"\n")
+	}
 
-		for _, option := range com.Options {
-			result.WriteString("\t")
-			result.WriteString(option.Name)
-			result.WriteString(" ")
-			result.WriteString(option.Description)
-			result.WriteString(" required ")
-			result.WriteString(strconv.FormatBool(option.Required))
-			result.WriteString(" type ")
-			result.WriteString(ApplicationCommandOptionToString(option.Type))
-			result.WriteString("\n")
-		}
+	if len(com.SubCommands) > 0 {
+		result.WriteString(" sub commands\n")
+	}
 
-		result.WriteString("\n\n")
+	result.WriteString("\n\n")
+
+	for i := range com.SubCommands {
+		cs.writeCommandHelp(result, path+" "+com.SubCommands[i].Name, &com.SubCommands[i])
+	}
+}
+
+// usage the positional form of the command e.g. "roll <sides> [count]"
+func (c *Command) usage(path string) string {
+	var result strings.Builder
+	result.WriteString(path)
+	for _, option := range c.Options {
+		if option.Required {
+			fmt.Fprintf(&result, " <%s>", option.Name)
+		} else {
+			fmt.Fprintf(&result, " [%s]", option.Name)
+		}
 	}
 
 	return result.String()
@@ -525,6 +1174,10 @@ func ApplicationCommandOptionToString(option discordgo.ApplicationCommandOptionT
 		return "Role"
 	case discordgo.ApplicationCommandOptionMentionable:
 		return "Mentionable"
+	case discordgo.ApplicationCommandOptionNumber:
+		return "Number"
+	case discordgo.ApplicationCommandOptionAttachment:
+		return "Attachment"
 	}
 
 	return ""
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,7 +45,7 @@ func TestHelpMessage(t *testing.T) {
 		},
 	}))
 
-	helpMsg := cs.getHelpMessage()
+	helpMsg := cs.getHelpMessage("")
 
 	assert.Contains(t, helpMsg, `"test$ nice" nice a test handler`)
 
@@ -287,4 +288,293 @@ func TestArgs(t *testing.T) {
 	testMessage.Content = msg
 	cs.Handler(testSession, testMessage)
 	assert.Equal(t, "cool", opt)
+
+	// Quoted args and extra whitespace
+	msg = "test$ nice  -bool true -string \"paul sarda\"  -int 69 -optional 'very cool'"
+	testMessage.Content = msg
+	cs.Handler(testSession, testMessage)
+	assert.Equal(t, "paul sarda", s)
+	assert.Equal(t, "very cool", opt)
+}
+
+func TestPositionalArgs(t *testing.T) {
+	cmd := Command{
+		Name: "roll",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:     "sides",
+				Type:     discordgo.ApplicationCommandOptionInteger,
+				Required: true,
+			},
+			{
+				Name:     "label",
+				Type:     discordgo.ApplicationCommandOptionString,
+				Required: false,
+			},
+		},
+	}
+
+	options, err := cmd.parseArgs(argContext{}, []string{"20"})
+	assert.NoError(t, err)
+	assert.Len(t, options, 1)
+	assert.Equal(t, int64(20), options[0].IntValue())
+
+	// Positional binds to the options not already named
+	options, err = cmd.parseArgs(argContext{}, []string{"-sides", "6", "damage"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), genOptionsMap(options)["sides"].IntValue())
+	assert.Equal(t, "damage", genOptionsMap(options)["label"].StringValue())
+
+	// Negative numbers are values not flags
+	options, err = cmd.parseArgs(argContext{}, []string{"-5"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-5), options[0].IntValue())
+
+	_, err = cmd.parseArgs(argContext{}, []string{"20", "damage", "extra"})
+	assert.True(t, errors.Is(err, ErrTooManyArgs))
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-label", "damage"})
+	assert.True(t, errors.Is(err, ErrInvalidArg))
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-sides"})
+	assert.True(t, errors.Is(err, ErrInvalidArg))
+
+	_, err = cmd.parseArgs(argContext{}, []string{"-unknown", "1"})
+	assert.True(t, errors.Is(err, ErrInvalidArg))
+
+	// Files attached to a command without attachment options are ignored
+	options, err = cmd.parseArgs(argContext{
+		message: &discordgo.Message{Attachments: []*discordgo.MessageAttachment{{ID: "screenshotID"}}},
+	}, []string{"20"})
+	assert.NoError(t, err)
+	assert.Len(t, options, 1)
+
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+	cmd.Handler = func(*discordgo.Session, Interaction) error { return nil }
+	assert.NoError(t, cs.AddCommand(cmd))
+	assert.Contains(t, cs.getHelpMessage(""), `usage "test$ roll <sides> [label]"`)
+}
+
+func TestNumberAndAttachmentArgs(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	var number float64
+	var attachment *discordgo.MessageAttachment
+	testHandler := func(sess *discordgo.Session, inter Interaction) error {
+		number = inter.Option("number").FloatValue()
+		attachment = inter.Attachment("file")
+		return nil
+	}
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "upload",
+		Handler:     testHandler,
+		Description: "upload a file",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "number",
+				Description: "number",
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Required:    true,
+			},
+			{
+				Name:        "file",
+				Description: "file",
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Required:    true,
+			},
+		},
+	}))
+
+	assert.Contains(t, cs.getHelpMessage(""), "file file required true type Attachment")
+
+	testSession := &discordgo.Session{
+		State: &discordgo.State{
+			Ready: discordgo.Ready{
+				User: &discordgo.User{
+					ID: "botID",
+				},
+			},
+		},
+	}
+
+	file := &discordgo.MessageAttachment{
+		ID:       "fileID",
+		URL:      "https://cdn.discordapp.com/bee.txt",
+		Filename: "bee.txt",
+		Size:     69,
+	}
+
+	// Prefix mode extra files are ignored
+	testMessage := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			Author: &discordgo.User{
+				ID: "messagerID",
+			},
+			Content:     "test$ upload 4.20",
+			Attachments: []*discordgo.MessageAttachment{file, {ID: "extraID"}},
+		},
+	}
+	cs.Handler(testSession, testMessage)
+	assert.Equal(t, 4.20, number)
+	assert.Equal(t, file, attachment)
+
+	// Slash mode
+	number, attachment = 0, nil
+	cs.IntreactionHandler(testSession, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "upload",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "number", Type: discordgo.ApplicationCommandOptionNumber, Value: 4.20},
+					{Name: "file", Type: discordgo.ApplicationCommandOptionAttachment, Value: "fileID"},
+				},
+				Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+					Attachments: map[string]*discordgo.MessageAttachment{"fileID": file},
+				},
+			},
+		},
+	})
+	assert.Equal(t, 4.20, number)
+	assert.Equal(t, file, attachment)
+}
+
+func TestSubCommands(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	var key string
+	called := ""
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "config",
+		Description: "change the config",
+		SubCommands: []Command{
+			{
+				Name:                     "set",
+				Description:              "set a key",
+				NameLocalizations:        map[discordgo.Locale]string{discordgo.French: "définir"},
+				DescriptionLocalizations: map[discordgo.Locale]string{discordgo.French: "définir une clé"},
+				Handler: func(s *discordgo.Session, i Interaction) error {
+					called = "set"
+					key = i.Option("key").StringValue()
+					return nil
+				},
+				Options: []*discordgo.ApplicationCommandOption{
+					{Name: "key", Description: "the key", Type: discordgo.ApplicationCommandOptionString, Required: true},
+				},
+			},
+			{
+				Name:        "role",
+				Description: "role config",
+				SubCommands: []Command{
+					{
+						Name:        "clear",
+						Description: "clear the roles",
+						Handler: func(s *discordgo.Session, i Interaction) error {
+							called = "role clear"
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}))
+
+	appCommand := cs.commands[0].asAppCommand()
+	assert.Len(t, appCommand.Options, 2)
+	assert.Equal(t, discordgo.ApplicationCommandOptionSubCommand, appCommand.Options[0].Type)
+	assert.Equal(t, "key", appCommand.Options[0].Options[0].Name)
+	assert.Equal(t, "définir", appCommand.Options[0].NameLocalizations[discordgo.French])
+	assert.Equal(t, "définir une clé", appCommand.Options[0].DescriptionLocalizations[discordgo.French])
+	assert.Equal(t, discordgo.ApplicationCommandOptionSubCommandGroup, appCommand.Options[1].Type)
+	assert.Equal(t, discordgo.ApplicationCommandOptionSubCommand, appCommand.Options[1].Options[0].Type)
+
+	helpMsg := cs.getHelpMessage("")
+	assert.Contains(t, helpMsg, `"test$ config" change the config sub commands`)
+	assert.Contains(t, helpMsg, `"test$ config set" set a key options`)
+	assert.Contains(t, helpMsg, `usage "test$ config set <key>"`)
+	assert.Contains(t, helpMsg, `"test$ config role clear" clear the roles`)
+
+	testSession := &discordgo.Session{
+		State: &discordgo.State{
+			Ready: discordgo.Ready{
+				User: &discordgo.User{
+					ID: "botID",
+				},
+			},
+		},
+	}
+
+	testMessage := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			Author: &discordgo.User{
+				ID: "messagerID",
+			},
+		},
+	}
+
+	// Prefix mode
+	testMessage.Content = "test$ config set -key x"
+	cs.Handler(testSession, testMessage)
+	assert.Equal(t, "set", called)
+	assert.Equal(t, "x", key)
+
+	testMessage.Content = "test$ config role clear"
+	cs.Handler(testSession, testMessage)
+	assert.Equal(t, "role clear", called)
+
+	testMessage.Content = "test$ config"
+	cs.Handler(testSession, testMessage)
+	assert.True(t, errors.Is(errored, ErrUnknownSubCommand))
+
+	// Slash mode
+	called, key = "", ""
+	cs.IntreactionHandler(testSession, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "config",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{
+						Name: "set",
+						Type: discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandInteractionDataOption{
+							{Name: "key", Type: discordgo.ApplicationCommandOptionString, Value: "y"},
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.Equal(t, "set", called)
+	assert.Equal(t, "y", key)
+
+	cs.IntreactionHandler(testSession, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			Type: discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "config",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{
+						Name: "role",
+						Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+						Options: []*discordgo.ApplicationCommandInteractionDataOption{
+							{Name: "clear", Type: discordgo.ApplicationCommandOptionSubCommand},
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.Equal(t, "role clear", called)
+
+	// Parent commands cannot have handlers
+	assert.Error(t, cs.AddCommand(Command{
+		Name:        "bad",
+		Handler:     func(*discordgo.Session, Interaction) error { return nil },
+		SubCommands: []Command{{Name: "child", Handler: func(*discordgo.Session, Interaction) error { return nil }}},
+	}))
 }
@@ -0,0 +1,59 @@
+package discom
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// ErrEphemeralAfterPublic an ephemeral response was given after a public response or defer was sent.
+	// Discord cannot make a message ephemeral once it has been sent.
+	ErrEphemeralAfterPublic = fmt.Errorf("cannot make a public response ephemeral")
+)
+
+// EphemeralFallback how an ephemeral response is sent for prefix commands which cannot be ephemeral
+type EphemeralFallback int
+
+const (
+	// EphemeralFallbackPublic the response is sent to the channel like any other response
+	EphemeralFallbackPublic EphemeralFallback = iota
+	// EphemeralFallbackDM the response is sent as a direct message to the author
+	EphemeralFallbackDM
+	// EphemeralFallbackDelete the response is sent to the channel then deleted after CommandSet.EphemeralDeleteAfter
+	EphemeralFallbackDelete
+)
+
+// DefaultEphemeralDeleteAfter used when CommandSet.EphemeralDeleteAfter is not set
+const DefaultEphemeralDeleteAfter = 10 * time.Second
+
+// ephemeralChannel the channel an ephemeral prefix response should be sent to
+func (d *discordMessage) ephemeralChannel(s *discordgo.Session) (string, error) {
+	if d.cs == nil || d.cs.EphemeralFallback != EphemeralFallbackDM {
+		return d.message.ChannelID, nil
+	}
+
+	channel, err := s.UserChannelCreate(d.message.Author.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return channel.ID, nil
+}
+
+// scheduleEphemeralDelete deletes the sent response if the fallback is EphemeralFallbackDelete
+func (d *discordMessage) scheduleEphemeralDelete(s *discordgo.Session, channelID, messageID string) {
+	if d.cs == nil || d.cs.EphemeralFallback != EphemeralFallbackDelete {
+		return
+	}
+
+	after := d.cs.EphemeralDeleteAfter
+	if after <= 0 {
+		after = DefaultEphemeralDeleteAfter
+	}
+
+	time.AfterFunc(after, func() {
+		s.ChannelMessageDelete(channelID, messageID)
+	})
+}
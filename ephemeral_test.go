@@ -0,0 +1,121 @@
+package discom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEphemeral(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "secret",
+		Description: "only you can see this",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			return i.Respond(s, Response{Content: "shh", Ephemeral: true})
+		},
+	}))
+
+	s, transport := newTestSession()
+	transport.responses["POST /api/v9/users/@me/channels"] = `{"id":"dmChannelID"}`
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "secret"},
+		},
+	})
+	requests := transport.Requests()
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, `"flags":64`)
+
+	testMessage := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ secret",
+		},
+	}
+
+	// Public by default
+	cs.Handler(s, testMessage)
+	requests = transport.Requests()[1:]
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "/api/v9/channels/channelID/messages", requests[0].Path)
+
+	// DM the author
+	cs.EphemeralFallback = EphemeralFallbackDM
+	cs.Handler(s, testMessage)
+	requests = transport.Requests()[2:]
+	assert.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Body, `"recipient_id":"messagerID"`)
+	assert.Equal(t, "/api/v9/channels/dmChannelID/messages", requests[1].Path)
+
+	// Deleted after a while
+	cs.EphemeralFallback = EphemeralFallbackDelete
+	cs.EphemeralDeleteAfter = 10 * time.Millisecond
+	cs.Handler(s, testMessage)
+	time.Sleep(50 * time.Millisecond)
+	requests = transport.Requests()[4:]
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "/api/v9/channels/channelID/messages", requests[0].Path)
+	assert.Equal(t, "DELETE", requests[1].Method)
+	assert.Contains(t, requests[1].Path, "/api/v9/channels/channelID/messages/")
+}
+
+func TestEphemeralDefer(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	slowSecret := func(s *discordgo.Session, i Interaction) error {
+		time.Sleep(30 * time.Millisecond)
+		return i.Respond(s, Response{Content: "shh", Ephemeral: true})
+	}
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:           "private",
+		Handler:        slowSecret,
+		AutoDefer:      time.Millisecond,
+		EphemeralDefer: true,
+	}))
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:      "leaky",
+		Handler:   slowSecret,
+		AutoDefer: time.Millisecond,
+	}))
+
+	s, transport := newTestSession()
+	slash := func(name string) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:    "interactionID",
+				Token: "token",
+				Type:  discordgo.InteractionApplicationCommand,
+				Data:  discordgo.ApplicationCommandInteractionData{Name: name},
+			},
+		}
+	}
+
+	// The defer is ephemeral so the response is too
+	cs.IntreactionHandler(s, slash("private"))
+	requests := transport.Requests()
+	assert.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Body, `"type":5`)
+	assert.Contains(t, requests[0].Body, `"flags":64`)
+	assert.Contains(t, requests[1].Body, `"content":"shh"`)
+	assert.NoError(t, errored)
+
+	// A public defer is never edited with an ephemeral response
+	cs.IntreactionHandler(s, slash("leaky"))
+	requests = transport.Requests()[2:]
+	assert.Len(t, requests, 1)
+	assert.NotContains(t, requests[0].Body, `"flags":64`)
+	assert.Equal(t, ErrEphemeralAfterPublic, errored)
+}
@@ -0,0 +1,122 @@
+package discom
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// FollowupMessage a message sent with Followup which can be changed later
+type FollowupMessage interface {
+	// ID the id of the discord message
+	ID() string
+	Edit(*discordgo.Session, Response) error
+	Delete(*discordgo.Session) error
+}
+
+// interactionMessage a message sent in response to an interaction.
+// An empty id is the original response.
+type interactionMessage struct {
+	interaction *discordgo.Interaction
+	id          string
+	overflow    Overflow
+}
+
+func (m *interactionMessage) ID() string {
+	return m.id
+}
+
+func (m *interactionMessage) Edit(s *discordgo.Session, res Response) error {
+	res, err := fitOverflow(res, m.overflow)
+	if err != nil {
+		return err
+	}
+
+	if m.id == "" {
+		_, err := s.InteractionResponseEdit(m.interaction, res.webhookEdit())
+		return err
+	}
+
+	_, err = s.FollowupMessageEdit(m.interaction, m.id, res.webhookEdit())
+	return err
+}
+
+func (m *interactionMessage) Delete(s *discordgo.Session) error {
+	if m.id == "" {
+		return s.InteractionResponseDelete(m.interaction)
+	}
+
+	return s.FollowupMessageDelete(m.interaction, m.id)
+}
+
+// channelMessage a message sent in response to a prefix command
+type channelMessage struct {
+	channelID string
+	id        string
+	overflow  Overflow
+}
+
+func (m *channelMessage) ID() string {
+	return m.id
+}
+
+func (m *channelMessage) Edit(s *discordgo.Session, res Response) error {
+	res, err := fitOverflow(res, m.overflow)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageEditComplex(res.messageEdit(m.channelID, m.id))
+	return err
+}
+
+func (m *channelMessage) Delete(s *discordgo.Session) error {
+	return s.ChannelMessageDelete(m.channelID, m.id)
+}
+
+func (d *discordInteraction) Followup(s *discordgo.Session, res Response) (FollowupMessage, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Discord needs a response before a follow up can be sent
+	if !d.sent {
+		if err := d.respond(s, res); err != nil {
+			return nil, err
+		}
+
+		return &interactionMessage{interaction: d.interaction, overflow: d.overflow}, nil
+	}
+
+	res = applyOverflow(res, d.overflow)
+	chunks := splitMessage(res.Content, maxMessageLength)
+	res.Content = chunks[0]
+
+	msg, err := s.FollowupMessageCreate(d.interaction, true, res.webhookParams())
+	if err != nil {
+		return nil, err
+	}
+
+	return &interactionMessage{interaction: d.interaction, id: msg.ID, overflow: d.overflow}, d.followupChunks(s, res, chunks[1:])
+}
+
+func (d *discordMessage) Followup(s *discordgo.Session, res Response) (FollowupMessage, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sentId == "" {
+		if err := d.respond(s, res); err != nil {
+			return nil, err
+		}
+
+		return &channelMessage{channelID: d.sentChannelId, id: d.sentId, overflow: d.overflow}, nil
+	}
+
+	res = applyOverflow(res, d.overflow)
+	chunks := splitMessage(res.Content, maxMessageLength)
+	res.Content = chunks[0]
+
+	msg, err := d.send(s, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, d.sendChunks(s, res, chunks[1:])
+}
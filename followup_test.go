@@ -0,0 +1,77 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowup(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	var handlerErr error
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "report",
+		Description: "make a report",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			handlerErr = func() error {
+				if err := i.Respond(s, Response{Content: "working"}); err != nil {
+					return err
+				}
+
+				results, err := i.Followup(s, Response{Content: "done here are the results"})
+				if err != nil {
+					return err
+				}
+
+				if err := results.Edit(s, Response{Content: "done here are the new results"}); err != nil {
+					return err
+				}
+
+				return results.Delete(s)
+			}()
+			return handlerErr
+		},
+	}))
+
+	s, transport := newTestSession()
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			AppID: "appID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "report"},
+		},
+	})
+	assert.NoError(t, handlerErr)
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 4)
+	assert.Equal(t, "/api/v9/webhooks/appID/token", requests[1].Path)
+	assert.Contains(t, requests[1].Body, "done here are the results")
+	assert.Equal(t, "PATCH", requests[2].Method)
+	assert.Equal(t, "/api/v9/webhooks/appID/token/messages/2", requests[2].Path)
+	assert.Equal(t, "DELETE", requests[3].Method)
+	assert.Equal(t, "/api/v9/webhooks/appID/token/messages/2", requests[3].Path)
+
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ report",
+		},
+	})
+	assert.NoError(t, handlerErr)
+
+	requests = transport.Requests()[4:]
+	assert.Len(t, requests, 4)
+	assert.Equal(t, "/api/v9/channels/channelID/messages", requests[0].Path)
+	assert.Equal(t, "/api/v9/channels/channelID/messages", requests[1].Path)
+	assert.Equal(t, "PATCH", requests[2].Method)
+	assert.Equal(t, "/api/v9/channels/channelID/messages/6", requests[2].Path)
+	assert.Equal(t, "DELETE", requests[3].Method)
+	assert.Equal(t, "/api/v9/channels/channelID/messages/6", requests[3].Path)
+}
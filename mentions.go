@@ -0,0 +1,150 @@
+package discom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownMention = fmt.Errorf("unknown mention")
+)
+
+var (
+	userMentionPattern    = regexp.MustCompile(`^<@!?(\d+)>$`)
+	channelMentionPattern = regexp.MustCompile(`^<#(\d+)>$`)
+	roleMentionPattern    = regexp.MustCompile(`^<@&(\d+)>$`)
+	snowflakePattern      = regexp.MustCompile(`^\d{15,21}$`)
+)
+
+func (ctx argContext) guild() *discordgo.Guild {
+	if ctx.session == nil || ctx.session.State == nil || ctx.message == nil || ctx.message.GuildID == "" {
+		return nil
+	}
+
+	guild, err := ctx.session.State.Guild(ctx.message.GuildID)
+	if err != nil {
+		return nil
+	}
+
+	return guild
+}
+
+// parseUser accepts <@id>, <@!id>, a raw id or the name of a member of the guild
+func (ctx argContext) parseUser(option *discordgo.ApplicationCommandOption, arg string) (string, error) {
+	if match := userMentionPattern.FindStringSubmatch(arg); match != nil {
+		return match[1], nil
+	}
+
+	if snowflakePattern.MatchString(arg) {
+		return arg, nil
+	}
+
+	name := strings.TrimPrefix(arg, "@")
+	if guild := ctx.guild(); guild != nil {
+		for _, member := range guild.Members {
+			if member.User == nil {
+				continue
+			}
+
+			if strings.EqualFold(member.Nick, name) ||
+				strings.EqualFold(member.User.Username, name) ||
+				strings.EqualFold(member.User.GlobalName, name) {
+				return member.User.ID, nil
+			}
+		}
+	}
+
+	return "", errors.Wrapf(ErrUnknownMention, "expected %s to be a user but was given %s", option.Name, arg)
+}
+
+// parseChannel accepts <#id>, a raw id or the name of a channel in the guild.
+// If the channel is known its type is checked against the option's ChannelTypes.
+func (ctx argContext) parseChannel(option *discordgo.ApplicationCommandOption, arg string) (string, error) {
+	var id string
+	if match := channelMentionPattern.FindStringSubmatch(arg); match != nil {
+		id = match[1]
+	} else if snowflakePattern.MatchString(arg) {
+		id = arg
+	} else if guild := ctx.guild(); guild != nil {
+		name := strings.TrimPrefix(arg, "#")
+		for _, channel := range guild.Channels {
+			if strings.EqualFold(channel.Name, name) {
+				id = channel.ID
+				break
+			}
+		}
+	}
+
+	if id == "" {
+		return "", errors.Wrapf(ErrUnknownMention, "expected %s to be a channel but was given %s", option.Name, arg)
+	}
+
+	if len(option.ChannelTypes) == 0 || ctx.session == nil || ctx.session.State == nil {
+		return id, nil
+	}
+
+	channel, err := ctx.session.State.Channel(id)
+	if err != nil {
+		return id, nil
+	}
+
+	for _, channelType := range option.ChannelTypes {
+		if channel.Type == channelType {
+			return id, nil
+		}
+	}
+
+	return "", errors.Wrapf(ErrInvalidArg, "%s is not an allowed channel type for %s", arg, option.Name)
+}
+
+// parseRole accepts <@&id>, a raw id or the name of a role in the guild
+func (ctx argContext) parseRole(option *discordgo.ApplicationCommandOption, arg string) (string, error) {
+	if match := roleMentionPattern.FindStringSubmatch(arg); match != nil {
+		return match[1], nil
+	}
+
+	if snowflakePattern.MatchString(arg) {
+		return arg, nil
+	}
+
+	name := strings.TrimPrefix(arg, "@")
+	if guild := ctx.guild(); guild != nil {
+		for _, role := range guild.Roles {
+			if strings.EqualFold(role.Name, name) {
+				return role.ID, nil
+			}
+		}
+	}
+
+	return "", errors.Wrapf(ErrUnknownMention, "expected %s to be a role but was given %s", option.Name, arg)
+}
+
+// parseMentionable accepts any user or role.
+// The id is kept as is since both RoleValue and UserValue accept a mentionable.
+func (ctx argContext) parseMentionable(option *discordgo.ApplicationCommandOption, arg string) (string, error) {
+	if match := roleMentionPattern.FindStringSubmatch(arg); match != nil {
+		return match[1], nil
+	}
+
+	if match := userMentionPattern.FindStringSubmatch(arg); match != nil {
+		return match[1], nil
+	}
+
+	if snowflakePattern.MatchString(arg) {
+		return arg, nil
+	}
+
+	if id, err := ctx.parseRole(option, arg); err == nil {
+		return id, nil
+	}
+
+	if id, err := ctx.parseUser(option, arg); err == nil {
+		return id, nil
+	}
+
+	return "", errors.Wrapf(ErrUnknownMention, "expected %s to be a user or role but was given %s", option.Name, arg)
+}
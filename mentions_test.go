@@ -0,0 +1,77 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMentionArgs(t *testing.T) {
+	state := discordgo.NewState()
+	assert.NoError(t, state.GuildAdd(&discordgo.Guild{
+		ID: "guildID",
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "111111111111111111", Username: "paul"}, Nick: "sarda"},
+		},
+		Channels: []*discordgo.Channel{
+			{ID: "222222222222222222", GuildID: "guildID", Name: "general", Type: discordgo.ChannelTypeGuildText},
+			{ID: "333333333333333333", GuildID: "guildID", Name: "voice", Type: discordgo.ChannelTypeGuildVoice},
+		},
+		Roles: []*discordgo.Role{
+			{ID: "444444444444444444", Name: "admin"},
+		},
+	}))
+
+	ctx := argContext{
+		session: &discordgo.Session{State: state},
+		message: &discordgo.Message{GuildID: "guildID"},
+	}
+
+	cmd := Command{
+		Name: "nice",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Name: "user", Type: discordgo.ApplicationCommandOptionUser},
+			{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+			{Name: "role", Type: discordgo.ApplicationCommandOptionRole},
+			{Name: "mentionable", Type: discordgo.ApplicationCommandOptionMentionable},
+		},
+	}
+
+	tests := []struct {
+		args     []string
+		name     string
+		expected string
+	}{
+		{[]string{"-user", "<@111111111111111111>"}, "user", "111111111111111111"},
+		{[]string{"-user", "<@!111111111111111111>"}, "user", "111111111111111111"},
+		{[]string{"-user", "111111111111111111"}, "user", "111111111111111111"},
+		{[]string{"-user", "@Sarda"}, "user", "111111111111111111"},
+		{[]string{"-channel", "<#222222222222222222>"}, "channel", "222222222222222222"},
+		{[]string{"-channel", "#general"}, "channel", "222222222222222222"},
+		{[]string{"-role", "<@&444444444444444444>"}, "role", "444444444444444444"},
+		{[]string{"-role", "admin"}, "role", "444444444444444444"},
+		{[]string{"-mentionable", "<@&444444444444444444>"}, "mentionable", "444444444444444444"},
+		{[]string{"-mentionable", "<@111111111111111111>"}, "mentionable", "111111111111111111"},
+		{[]string{"-mentionable", "paul"}, "mentionable", "111111111111111111"},
+	}
+
+	for _, test := range tests {
+		options, err := cmd.parseArgs(ctx, test.args)
+		assert.NoError(t, err, test.args)
+		option := genOptionsMap(options)[test.name]
+		assert.Equal(t, test.expected, option.Value, test.args)
+	}
+
+	options, err := cmd.parseArgs(ctx, []string{"-channel", "<#222222222222222222>", "-role", "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "general", genOptionsMap(options)["channel"].ChannelValue(ctx.session).Name)
+	assert.Equal(t, "admin", genOptionsMap(options)["role"].RoleValue(ctx.session, "guildID").Name)
+
+	_, err = cmd.parseArgs(ctx, []string{"-user", "nobody"})
+	assert.True(t, errors.Is(err, ErrUnknownMention))
+
+	_, err = cmd.parseArgs(ctx, []string{"-channel", "<#333333333333333333>"})
+	assert.True(t, errors.Is(err, ErrInvalidArg))
+}
@@ -0,0 +1,27 @@
+package discom
+
+// Middleware wraps a command's handler to run code before and after it.
+// A middleware can stop the command by returning without calling next.
+type Middleware func(next CommandHandler) CommandHandler
+
+// Use adds middleware which wraps the handler of every command.
+// Middleware added first runs first.
+func (cs *CommandSet) Use(middleware ...Middleware) {
+	cs.middleware = append(cs.middleware, middleware...)
+}
+
+// wrapHandler cmd's handler wrapped in the command set's middleware then the middleware
+// of each command from the root down to cmd
+func (cs *CommandSet) wrapHandler(root, cmd *Command) CommandHandler {
+	middleware := append([]Middleware(nil), cs.middleware...)
+	for _, com := range root.pathTo(cmd) {
+		middleware = append(middleware, com.Middleware...)
+	}
+
+	handler := cmd.Handler
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
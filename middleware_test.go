@@ -0,0 +1,105 @@
+package discom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next CommandHandler) CommandHandler {
+			return func(s *discordgo.Session, i Interaction) error {
+				calls = append(calls, name+" before")
+				err := next(s, i)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+
+	cs.Use(record("first"), record("second"))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:       "config",
+		Middleware: []Middleware{record("config")},
+		SubCommands: []Command{
+			{
+				Name:       "set",
+				Middleware: []Middleware{record("set")},
+				Handler: func(*discordgo.Session, Interaction) error {
+					calls = append(calls, "handler")
+					return nil
+				},
+			},
+		},
+	}))
+
+	blocked := fmt.Errorf("blocked")
+	assert.NoError(t, cs.AddCommand(Command{
+		Name: "blocked",
+		Middleware: []Middleware{func(CommandHandler) CommandHandler {
+			return func(*discordgo.Session, Interaction) error {
+				return blocked
+			}
+		}},
+		Handler: func(*discordgo.Session, Interaction) error {
+			calls = append(calls, "handler")
+			return nil
+		},
+	}))
+
+	s, _ := newTestSession()
+
+	expected := []string{
+		"first before", "second before", "config before", "set before",
+		"handler",
+		"set after", "config after", "second after", "first after",
+	}
+
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "userID"},
+			Content:   "test$ config set",
+		},
+	})
+	assert.Equal(t, expected, calls)
+
+	calls = nil
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			User:  &discordgo.User{ID: "userID"},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "config",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "set", Type: discordgo.ApplicationCommandOptionSubCommand},
+				},
+			},
+		},
+	})
+	assert.Equal(t, expected, calls)
+
+	// Middleware can stop the handler running
+	calls = nil
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "userID"},
+			Content:   "test$ blocked",
+		},
+	})
+	assert.Equal(t, []string{"first before", "second before", "second after", "first after"}, calls)
+	assert.Equal(t, blocked, errored)
+}
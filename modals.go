@@ -0,0 +1,172 @@
+package discom
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrModalUnsupported modals can only be shown in response to a slash command or component
+	ErrModalUnsupported = fmt.Errorf("modals are not supported for prefix commands")
+)
+
+// Modal a form shown to the user.
+// The submission is handled by the route added with AddModal matching CustomID.
+type Modal struct {
+	CustomID string
+	Title    string
+	// Inputs each input is shown on its own row
+	Inputs []discordgo.TextInput
+}
+
+func (m *Modal) asInteractionResponse() *discordgo.InteractionResponse {
+	rows := make([]discordgo.MessageComponent, len(m.Inputs))
+	for i, input := range m.Inputs {
+		rows[i] = discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{input},
+		}
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   m.CustomID,
+			Title:      m.Title,
+			Components: rows,
+		},
+	}
+}
+
+// ModalInteraction a submitted modal
+type ModalInteraction interface {
+	Interaction
+	// Update replaces the message the modal was opened from if it was opened by a component
+	Update(*discordgo.Session, Response) error
+	// DeferUpdate acknowledges the submission without changing the message so Update can be called later
+	DeferUpdate(*discordgo.Session) error
+	// CustomID the full custom id of the modal
+	CustomID() string
+	// Param a value captured by a {name} in the route's pattern
+	Param(name string) string
+	// Field the value submitted for the input with the custom id
+	Field(customID string) string
+}
+
+// ModalHandler called when a modal matching a route is submitted
+// Error should only return data your fine with the user seeing
+type ModalHandler func(*discordgo.Session, ModalInteraction) error
+
+type modalRoute struct {
+	pattern *regexp.Regexp
+	handler ModalHandler
+}
+
+// AddModal Use this to handle modals whose custom id matches pattern.
+// The pattern is the same as AddComponent.
+func (cs *CommandSet) AddModal(pattern string, handler ModalHandler) error {
+	if handler == nil {
+		return fmt.Errorf("invalid handler is nil")
+	}
+
+	compiled, err := compileComponentPattern(pattern)
+	if err != nil {
+		return errors.Wrap(err, "invalid modal pattern")
+	}
+
+	cs.modals = append(cs.modals, modalRoute{
+		pattern: compiled,
+		handler: handler,
+	})
+
+	return nil
+}
+
+// modalFields collects the values of every text input in a submitted modal
+func modalFields(components []discordgo.MessageComponent) map[string]string {
+	result := make(map[string]string)
+	for _, component := range components {
+		switch c := component.(type) {
+		case *discordgo.ActionsRow:
+			for k, v := range modalFields(c.Components) {
+				result[k] = v
+			}
+		case *discordgo.TextInput:
+			result[c.CustomID] = c.Value
+		}
+	}
+
+	return result
+}
+
+func (cs *CommandSet) handleModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	for _, route := range cs.modals {
+		params, ok := matchCustomID(route.pattern, data.CustomID)
+		if !ok {
+			continue
+		}
+
+		// Fields are also exposed as string options so Option works the same as for commands
+		fields := modalFields(data.Components)
+		var options []*discordgo.ApplicationCommandInteractionDataOption
+		for name, value := range fields {
+			options = append(options, &discordgo.ApplicationCommandInteractionDataOption{
+				Name:  name,
+				Type:  discordgo.ApplicationCommandOptionString,
+				Value: value,
+			})
+		}
+
+		inter := &discordModal{
+			discordInteraction: discordInteraction{
+				interaction: i.Interaction,
+				overflow:    cs.overflow(nil),
+				options:     options,
+			},
+			customID: data.CustomID,
+			params:   params,
+			fields:   fields,
+		}
+
+		done, ok := cs.begin(inter, data.CustomID, 0)
+		if !ok {
+			return
+		}
+		defer done()
+
+		if err := route.handler(s, inter); err != nil {
+			cs.ErrorHandler(s, inter, err)
+		}
+		return
+	}
+}
+
+type discordModal struct {
+	discordInteraction
+	customID string
+	params   map[string]string
+	fields   map[string]string
+}
+
+func (d *discordModal) CustomID() string {
+	return d.customID
+}
+
+func (d *discordModal) Param(name string) string {
+	return d.params[name]
+}
+
+func (d *discordModal) Field(customID string) string {
+	return d.fields[customID]
+}
+
+func (d *discordModal) Update(s *discordgo.Session, res Response) error {
+	return d.update(s, res)
+}
+
+func (d *discordModal) DeferUpdate(s *discordgo.Session) error {
+	return d.deferUpdate(s)
+}
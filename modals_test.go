@@ -0,0 +1,87 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModals(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	var respondErr error
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "feedback",
+		Description: "give feedback",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			respondErr = i.RespondModal(s, Modal{
+				CustomID: "feedback:42",
+				Title:    "Feedback",
+				Inputs: []discordgo.TextInput{
+					{CustomID: "title", Label: "Title", Style: discordgo.TextInputShort, Required: true},
+					{CustomID: "body", Label: "Body", Style: discordgo.TextInputParagraph},
+				},
+			})
+			return nil
+		},
+	}))
+
+	var id, title, body string
+	assert.NoError(t, cs.AddModal("feedback:{id}", func(s *discordgo.Session, i ModalInteraction) error {
+		id = i.Param("id")
+		title = i.Field("title")
+		body = i.Option("body").StringValue()
+		return nil
+	}))
+
+	s, transport := newTestSession()
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "feedback"},
+		},
+	})
+	assert.NoError(t, respondErr)
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, `"type":9`)
+	assert.Contains(t, requests[0].Body, `"custom_id":"feedback:42"`)
+	assert.Contains(t, requests[0].Body, `"custom_id":"body"`)
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			Token: "token",
+			Type:  discordgo.InteractionModalSubmit,
+			Data: discordgo.ModalSubmitInteractionData{
+				CustomID: "feedback:42",
+				Components: []discordgo.MessageComponent{
+					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						&discordgo.TextInput{CustomID: "title", Value: "bees"},
+					}},
+					&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						&discordgo.TextInput{CustomID: "body", Value: "more bees"},
+					}},
+				},
+			},
+		},
+	})
+	assert.Equal(t, "42", id)
+	assert.Equal(t, "bees", title)
+	assert.Equal(t, "more bees", body)
+
+	// Prefix commands cannot show modals
+	testMessage := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			Author:  &discordgo.User{ID: "messagerID"},
+			Content: "test$ feedback",
+		},
+	}
+	cs.Handler(s, testMessage)
+	assert.ErrorIs(t, respondErr, ErrModalUnsupported)
+}
@@ -0,0 +1,78 @@
+package discom
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrContentTooLong an edit or update was too long for one message with OverflowSplit.
+	// Only new responses can be split into more messages.
+	ErrContentTooLong = fmt.Errorf("content too long for one message")
+)
+
+// Overflow what to do with a response too long for one discord message
+type Overflow int
+
+const (
+	// OverflowDefault use the CommandSet's Overflow which defaults to OverflowSplit
+	OverflowDefault Overflow = iota
+	// OverflowSplit send the response as multiple messages
+	OverflowSplit
+	// OverflowTruncate cut the response short ending it with an ellipsis
+	OverflowTruncate
+	// OverflowAttach send the response as a file named OverflowFileName
+	OverflowAttach
+)
+
+// OverflowFileName the name of the file used by OverflowAttach
+const OverflowFileName = "output.txt"
+
+const ellipsis = "…"
+
+// overflow the policy for the command falling back to the command set's
+func (cs *CommandSet) overflow(cmd *Command) Overflow {
+	if cmd != nil && cmd.Overflow != OverflowDefault {
+		return cmd.Overflow
+	}
+
+	return cs.Overflow
+}
+
+// applyOverflow truncates or attaches the content if it is too long.
+// OverflowSplit is left to the responder.
+func applyOverflow(res Response, policy Overflow) Response {
+	if utf8.RuneCountInString(res.Content) <= maxMessageLength {
+		return res
+	}
+
+	switch policy {
+	case OverflowTruncate:
+		runes := []rune(res.Content)
+		res.Content = string(runes[:maxMessageLength-utf8.RuneCountInString(ellipsis)]) + ellipsis
+	case OverflowAttach:
+		res.Files = append(append([]*discordgo.File(nil), res.Files...), &discordgo.File{
+			Name:        OverflowFileName,
+			ContentType: "text/plain",
+			Reader:      strings.NewReader(res.Content),
+		})
+		res.Content = ""
+	}
+
+	return res
+}
+
+// fitOverflow applies the policy to a response which replaces one message such as an edit.
+// OverflowSplit can't send more messages so content which is still too long is an error.
+func fitOverflow(res Response, policy Overflow) (Response, error) {
+	res = applyOverflow(res, policy)
+	if length := utf8.RuneCountInString(res.Content); length > maxMessageLength {
+		return res, errors.Wrapf(ErrContentTooLong, "%d characters is over the limit of %d", length, maxMessageLength)
+	}
+
+	return res, nil
+}
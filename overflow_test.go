@@ -0,0 +1,138 @@
+package discom
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyOverflow(t *testing.T) {
+	short := Response{Content: "bee movie"}
+	assert.Equal(t, short, applyOverflow(short, OverflowTruncate))
+
+	long := Response{Content: strings.Repeat("蜜", 2500)}
+	assert.Equal(t, long, applyOverflow(long, OverflowSplit))
+
+	truncated := applyOverflow(long, OverflowTruncate)
+	assert.Equal(t, maxMessageLength, utf8.RuneCountInString(truncated.Content))
+	assert.True(t, strings.HasSuffix(truncated.Content, ellipsis))
+
+	attached := applyOverflow(long, OverflowAttach)
+	assert.Equal(t, "", attached.Content)
+	assert.Len(t, attached.Files, 1)
+	assert.Equal(t, OverflowFileName, attached.Files[0].Name)
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+	cs.Overflow = OverflowTruncate
+
+	content := strings.Repeat(strings.Repeat("a", 99)+"\n", 45)
+	handler := func(s *discordgo.Session, i Interaction) error {
+		return i.Respond(s, Response{Content: content})
+	}
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "logs",
+		Description: "dump the logs",
+		Overflow:    OverflowAttach,
+		Handler:     handler,
+	}))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "table",
+		Description: "dump a table",
+		Handler:     handler,
+	}))
+
+	s, transport := newTestSession()
+	testMessage := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+		},
+	}
+
+	testMessage.Content = "test$ logs"
+	cs.Handler(s, testMessage)
+	requests := transport.Requests()
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, `filename="output.txt"`)
+
+	testMessage.Content = "test$ table"
+	cs.Handler(s, testMessage)
+	requests = transport.Requests()[1:]
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, ellipsis)
+}
+
+func TestOverflowEdits(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	content := strings.Repeat(strings.Repeat("a", 99)+"\n", 45)
+	assert.NoError(t, cs.AddComponent("update", func(s *discordgo.Session, i ComponentInteraction) error {
+		return i.Update(s, Response{Content: content})
+	}))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "report",
+		Description: "make a report",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			msg, err := i.Followup(s, Response{Content: "working"})
+			if err != nil {
+				return err
+			}
+			return msg.Edit(s, Response{Content: content})
+		},
+	}))
+
+	s, transport := newTestSession()
+
+	// Split can't send more messages for an update or edit
+	cs.IntreactionHandler(s, componentInteraction("update"))
+	assert.True(t, errors.Is(errored, ErrContentTooLong))
+	assert.Len(t, transport.Requests(), 0)
+
+	errored = nil
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ report",
+		},
+	})
+	assert.True(t, errors.Is(errored, ErrContentTooLong))
+	assert.Len(t, transport.Requests(), 1)
+
+	// Other policies fit the content into the one message
+	cs.Overflow = OverflowTruncate
+	errored = nil
+	cs.IntreactionHandler(s, componentInteraction("update"))
+	assert.NoError(t, errored)
+	requests := transport.Requests()[1:]
+	assert.Len(t, requests, 1)
+	assert.Contains(t, requests[0].Body, ellipsis)
+
+	cs.Overflow = OverflowAttach
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			AppID: "appID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "report"},
+		},
+	})
+	assert.NoError(t, errored)
+	requests = transport.Requests()[2:]
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "PATCH", requests[1].Method)
+	assert.Contains(t, requests[1].Body, `filename="output.txt"`)
+}
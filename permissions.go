@@ -0,0 +1,112 @@
+package discom
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotAllowed the author isn't allowed to use the prefix command where they used it
+	ErrNotAllowed = fmt.Errorf("not allowed")
+)
+
+// PermissionError returned when the author of a prefix command is missing the command's DefaultMemberPermissions
+type PermissionError struct {
+	Command string
+	// Missing the permissions the author needs but doesn't have
+	Missing int64
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("you do not have permission to use %s", e.Command)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return ErrNotAllowed
+}
+
+// allowedIn reports whether the command can be used in a guild or DM
+func (c *Command) allowedIn(guild bool) bool {
+	if !guild && c.DMPermission != nil && !*c.DMPermission {
+		return false
+	}
+
+	if len(c.Contexts) == 0 {
+		return true
+	}
+
+	want := InteractionContextBotDM
+	if guild {
+		want = InteractionContextGuild
+	}
+
+	for _, context := range c.Contexts {
+		if context == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorPermissions the permissions of the message author in the channel it was sent in
+func authorPermissions(s *discordgo.Session, m *discordgo.Message) (int64, error) {
+	if m.Member != nil {
+		return s.State.MessagePermissions(m)
+	}
+
+	return s.State.UserChannelPermissions(m.Author.ID, m.ChannelID)
+}
+
+// checkAllowed applies the same rules discord applies to slash commands to a prefix command
+func checkAllowed(s *discordgo.Session, cmd *Command, m *discordgo.Message) error {
+	guild := m.GuildID != ""
+	if !cmd.allowedIn(guild) {
+		if guild {
+			return errors.Wrapf(ErrNotAllowed, "%s can only be used in DMs", cmd.Name)
+		}
+		return errors.Wrapf(ErrNotAllowed, "%s cannot be used in DMs", cmd.Name)
+	}
+
+	if !guild {
+		return nil
+	}
+
+	if cmd.NSFW {
+		channel, err := s.State.Channel(m.ChannelID)
+		if err != nil {
+			return errors.Wrapf(ErrNotAllowed, "unable to find channel for %s", cmd.Name)
+		}
+
+		if !channel.NSFW {
+			return errors.Wrapf(ErrNotAllowed, "%s can only be used in age restricted channels", cmd.Name)
+		}
+	}
+
+	if cmd.DefaultMemberPermissions == nil {
+		return nil
+	}
+
+	// No permissions means only administrators
+	required := *cmd.DefaultMemberPermissions
+	if required == 0 {
+		required = discordgo.PermissionAdministrator
+	}
+
+	perms, err := authorPermissions(s, m)
+	if err != nil {
+		return &PermissionError{Command: cmd.Name, Missing: required}
+	}
+
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return nil
+	}
+
+	if missing := required &^ perms; missing != 0 {
+		return &PermissionError{Command: cmd.Name, Missing: missing}
+	}
+
+	return nil
+}
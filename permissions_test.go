@@ -0,0 +1,90 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixPermissions(t *testing.T) {
+	var errored error
+	cs, _ := CreateCommandSet("test$", func(_ *discordgo.Session, _ Interaction, err error) {
+		errored = err
+	})
+
+	var ran []string
+	handler := func(name string) CommandHandler {
+		return func(*discordgo.Session, Interaction) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	kick := int64(discordgo.PermissionKickMembers)
+	noDMs := false
+	assert.NoError(t, cs.AddCommand(Command{Name: "kick", Handler: handler("kick"), DefaultMemberPermissions: &kick}))
+	assert.NoError(t, cs.AddCommand(Command{Name: "nuke", Handler: handler("nuke"), DefaultMemberPermissions: new(int64)}))
+	assert.NoError(t, cs.AddCommand(Command{Name: "guildonly", Handler: handler("guildonly"), DMPermission: &noDMs}))
+	assert.NoError(t, cs.AddCommand(Command{Name: "dmonly", Handler: handler("dmonly"), Contexts: []InteractionContextType{InteractionContextBotDM}}))
+	assert.NoError(t, cs.AddCommand(Command{Name: "spicy", Handler: handler("spicy"), NSFW: true}))
+
+	s, _ := newTestSession()
+	assert.NoError(t, s.State.GuildAdd(&discordgo.Guild{
+		ID: "guildID",
+		Roles: []*discordgo.Role{
+			{ID: "guildID", Name: "@everyone", Permissions: discordgo.PermissionSendMessages},
+			{ID: "modID", Name: "mod", Permissions: discordgo.PermissionKickMembers},
+		},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "userID"}},
+			{User: &discordgo.User{ID: "modID"}, Roles: []string{"modID"}},
+		},
+		Channels: []*discordgo.Channel{
+			{ID: "generalID", GuildID: "guildID", Type: discordgo.ChannelTypeGuildText},
+			{ID: "spicyID", GuildID: "guildID", Type: discordgo.ChannelTypeGuildText, NSFW: true},
+		},
+	}))
+
+	run := func(authorID, guildID, channelID, content string) error {
+		ran, errored = nil, nil
+		cs.Handler(s, &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				GuildID:   guildID,
+				ChannelID: channelID,
+				Author:    &discordgo.User{ID: authorID},
+				Content:   content,
+			},
+		})
+		return errored
+	}
+
+	err := run("userID", "guildID", "generalID", "test$ kick")
+	var permErr *PermissionError
+	assert.True(t, errors.As(err, &permErr))
+	assert.Equal(t, int64(discordgo.PermissionKickMembers), permErr.Missing)
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.Empty(t, ran)
+
+	assert.NoError(t, run("modID", "guildID", "generalID", "test$ kick"))
+	assert.Equal(t, []string{"kick"}, ran)
+
+	// No permissions means administrators only
+	err = run("modID", "guildID", "generalID", "test$ nuke")
+	assert.True(t, errors.As(err, &permErr))
+	assert.Equal(t, int64(discordgo.PermissionAdministrator), permErr.Missing)
+
+	err = run("userID", "", "dmChannelID", "test$ guildonly")
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.NoError(t, run("userID", "guildID", "generalID", "test$ guildonly"))
+
+	err = run("userID", "guildID", "generalID", "test$ dmonly")
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.NoError(t, run("userID", "", "dmChannelID", "test$ dmonly"))
+
+	err = run("userID", "guildID", "generalID", "test$ spicy")
+	assert.True(t, errors.Is(err, ErrNotAllowed))
+	assert.NoError(t, run("userID", "guildID", "spicyID", "test$ spicy"))
+	assert.Equal(t, []string{"spicy"}, ran)
+}
@@ -0,0 +1,478 @@
+package discom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// SyncAction what a step of a sync plan does to a command
+type SyncAction int
+
+const (
+	SyncCreate SyncAction = iota + 1
+	SyncEdit
+	SyncDelete
+)
+
+func (a SyncAction) String() string {
+	switch a {
+	case SyncCreate:
+		return "create"
+	case SyncEdit:
+		return "edit"
+	case SyncDelete:
+		return "delete"
+	}
+
+	return fmt.Sprintf("SyncAction(%d)", a)
+}
+
+// SyncMode how a sync plan is applied
+type SyncMode int
+
+const (
+	// SyncIncremental each command is created, edited or deleted on its own
+	// leaving unchanged commands alone
+	SyncIncremental SyncMode = iota
+	// SyncBulkOverwrite every guild with a change has all its commands replaced in one request
+	SyncBulkOverwrite
+)
+
+var (
+	// ErrInvalidSyncPlan the plan is missing something needed to apply it
+	ErrInvalidSyncPlan = fmt.Errorf("invalid sync plan")
+)
+
+// SyncError the steps which failed when applying a plan
+type SyncError struct {
+	Errors []error
+}
+
+func (e *SyncError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d sync errors: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *SyncError) Unwrap() []error {
+	return e.Errors
+}
+
+// FieldDiff a field which is different on discord to the command set.
+// The values are JSON.
+type FieldDiff struct {
+	Field    string
+	Existing string
+	Desired  string
+}
+
+// SyncStep one change to the commands registered with discord
+type SyncStep struct {
+	Action SyncAction
+	// GuildID the guild the command is registered in "" is global
+	GuildID string
+	// Type the kind of command 0 is a chat command
+	Type discordgo.ApplicationCommandType
+	Name string
+	// ID the existing command's id for edits and deletes
+	ID string
+	// Command what the command will be for creates and edits
+	Command *AppCommand
+	// Diffs what will change for edits
+	Diffs []FieldDiff
+}
+
+func (step SyncStep) String() string {
+	scope := "global"
+	if step.GuildID != "" {
+		scope = "guild " + step.GuildID
+	}
+
+	kind := ""
+	switch step.Type {
+	case discordgo.UserApplicationCommand:
+		kind = "user "
+	case discordgo.MessageApplicationCommand:
+		kind = "message "
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "%s %s %scommand \"%s\"", step.Action, scope, kind, step.Name)
+	for _, diff := range step.Diffs {
+		fmt.Fprintf(&result, "\n\t%s %s -> %s", diff.Field, diff.Existing, diff.Desired)
+	}
+
+	return result.String()
+}
+
+// SyncPlan the changes needed to make discord match the command set.
+// Create one with CommandSet.PlanSync and run it with CommandSet.ApplySync.
+type SyncPlan struct {
+	// AppID the application the commands belong to
+	AppID string
+	// Mode how the plan is applied
+	Mode  SyncMode
+	Steps []SyncStep
+	// Unchanged the guilds which were skipped because CommandSet.SyncStore
+	// shows their commands haven't changed since the last sync
+	Unchanged []string
+	// Guilds what each planned guild will have once the plan is applied.
+	// Needed for SyncBulkOverwrite and to save the sync state.
+	Guilds []SyncGuild
+}
+
+// SyncGuild the commands a guild will have once a plan is applied "" being global
+type SyncGuild struct {
+	GuildID string
+	// Commands every command the guild will have
+	Commands []*AppCommand
+	// CommandIDs the ids of the commands which already exist by CommandKey
+	CommandIDs map[string]string
+}
+
+// guild the planned guild nil if it isn't in the plan
+func (p *SyncPlan) guild(guildID string) *SyncGuild {
+	for i := range p.Guilds {
+		if p.Guilds[i].GuildID == guildID {
+			return &p.Guilds[i]
+		}
+	}
+
+	return nil
+}
+
+// guildIDs every guild in the plan in the order they were planned
+func (p *SyncPlan) guildIDs() []string {
+	var result []string
+	seen := make(map[string]bool)
+	add := func(guildID string) {
+		if !seen[guildID] {
+			seen[guildID] = true
+			result = append(result, guildID)
+		}
+	}
+
+	for _, guild := range p.Guilds {
+		add(guild.GuildID)
+	}
+	for _, step := range p.Steps {
+		add(step.GuildID)
+	}
+
+	return result
+}
+
+// validate checks the plan has everything needed to apply it
+func (p *SyncPlan) validate() error {
+	if p.AppID == "" {
+		return errors.Wrap(ErrInvalidSyncPlan, "missing app id")
+	}
+
+	for _, step := range p.Steps {
+		switch step.Action {
+		case SyncCreate, SyncEdit, SyncDelete:
+		default:
+			return errors.Wrapf(ErrInvalidSyncPlan, "unknown action %s", step.Action)
+		}
+
+		if step.Action != SyncCreate && step.ID == "" {
+			return errors.Wrapf(ErrInvalidSyncPlan, "%s is missing the command id", step)
+		}
+
+		if step.Action != SyncDelete && step.Command == nil {
+			return errors.Wrapf(ErrInvalidSyncPlan, "%s is missing the command", step)
+		}
+
+		if p.Mode == SyncBulkOverwrite && p.guild(step.GuildID) == nil {
+			return errors.Wrapf(ErrInvalidSyncPlan, "bulk overwrite is missing the commands for guild '%s'", step.GuildID)
+		}
+	}
+
+	return nil
+}
+
+// Empty reports whether discord already matches the command set
+func (p *SyncPlan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+func (p *SyncPlan) String() string {
+	if p.Empty() {
+		return "no changes"
+	}
+
+	lines := make([]string, len(p.Steps))
+	for i, step := range p.Steps {
+		lines[i] = step.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func jsonField(value interface{}) string {
+	result, _ := json.Marshal(value)
+	return string(result)
+}
+
+// commandDiffs the fields which differ between the command on discord and the desired command.
+// Both are normalised first so discord's defaults don't show up as changes.
+func commandDiffs(guildID string, existing, desired *AppCommand) []FieldDiff {
+	var result []FieldDiff
+	add := func(field string, a, b interface{}) {
+		aJson, bJson := jsonField(a), jsonField(b)
+		if !bytes.Equal([]byte(aJson), []byte(bJson)) {
+			result = append(result, FieldDiff{Field: field, Existing: aJson, Desired: bJson})
+		}
+	}
+
+	existing, desired = existing.normalised(guildID), desired.normalised(guildID)
+	add("type", existing.Type, desired.Type)
+	add("name_localizations", existing.NameLocalizations, desired.NameLocalizations)
+	add("description", existing.Description, desired.Description)
+	add("description_localizations", existing.DescriptionLocalizations, desired.DescriptionLocalizations)
+	add("options", existing.Options, desired.Options)
+	add("default_member_permissions", existing.DefaultMemberPermissions, desired.DefaultMemberPermissions)
+	add("dm_permission", existing.DMPermission, desired.DMPermission)
+	add("nsfw", existing.NSFW, desired.NSFW)
+	add("contexts", existing.Contexts, desired.Contexts)
+
+	return result
+}
+
+// PlanSync works out what SyncAppCommands would change without changing anything.
+// guildIDs are the same as SyncAppCommands.
+// If CommandSet.SyncStore is set guilds whose commands haven't changed since the last sync are skipped
+// without asking discord for their commands.
+func (cs *CommandSet) PlanSync(s *discordgo.Session, guildIDs ...string) (*SyncPlan, error) {
+	plan := &SyncPlan{
+		AppID: s.State.User.ID,
+		Mode:  cs.SyncMode,
+	}
+
+	// Guilds synced before may have commands left over which need deleting
+	if cs.SyncStore != nil {
+		synced, err := cs.SyncStore.GuildIDs(plan.AppID)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load the guilds synced before")
+		}
+		guildIDs = append(append([]string(nil), guildIDs...), synced...)
+	}
+
+	byGuild, order := cs.commandsByGuild(guildIDs)
+
+	for _, guildID := range order {
+		desired := []*AppCommand{}
+		for _, cmd := range byGuild[guildID] {
+			desired = append(desired, cmd.asAppCommand())
+		}
+
+		hash := hashAppCommands(guildID, desired)
+		if cs.SyncStore != nil {
+			state, err := cs.SyncStore.Load(plan.AppID, guildID)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to load sync state for guild '%s'", guildID)
+			}
+
+			if state != nil && state.Hash == hash {
+				plan.Unchanged = append(plan.Unchanged, guildID)
+				continue
+			}
+		}
+
+		existingCmds, err := appCommands(s, plan.AppID, guildID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get commands for guild '%s'", guildID)
+		}
+
+		plan.Steps = append(plan.Steps, planGuild(guildID, existingCmds, byGuild[guildID])...)
+
+		ids := make(map[string]string)
+		for _, cmd := range existingCmds {
+			ids[cmd.key()] = cmd.ID
+		}
+		plan.Guilds = append(plan.Guilds, SyncGuild{
+			GuildID:    guildID,
+			Commands:   desired,
+			CommandIDs: ids,
+		})
+	}
+
+	return plan, nil
+}
+
+// planGuild the steps to make the existing commands in the guild match cmds
+func planGuild(guildID string, existingCmds []*AppCommand, cmds []Command) []SyncStep {
+	var result []SyncStep
+
+	existing := make(map[string]*AppCommand)
+	for _, v := range existingCmds {
+		existing[v.key()] = v
+	}
+
+	desired := make(map[string]bool)
+	for _, cmd := range cmds {
+		appCmd := cmd.asAppCommand()
+		desired[appCmd.key()] = true
+
+		v, ok := existing[appCmd.key()]
+		if !ok {
+			result = append(result, SyncStep{
+				Action:  SyncCreate,
+				GuildID: guildID,
+				Type:    cmd.Type,
+				Name:    cmd.Name,
+				Command: appCmd,
+			})
+			continue
+		}
+
+		if diffs := commandDiffs(guildID, v, appCmd); len(diffs) > 0 {
+			result = append(result, SyncStep{
+				Action:  SyncEdit,
+				GuildID: guildID,
+				Type:    cmd.Type,
+				Name:    cmd.Name,
+				ID:      v.ID,
+				Command: appCmd,
+				Diffs:   diffs,
+			})
+		}
+	}
+
+	for _, v := range existingCmds {
+		if !desired[v.key()] {
+			result = append(result, SyncStep{
+				Action:  SyncDelete,
+				GuildID: guildID,
+				Type:    v.Type,
+				Name:    v.Name,
+				ID:      v.ID,
+			})
+		}
+	}
+
+	return result
+}
+
+// ApplySync makes exactly the changes in the plan.
+// A failed step does not stop the rest of the plan, every failure is returned in a SyncError.
+// If CommandSet.SyncStore is set the state of each guild in plan.Guilds which synced without errors is saved.
+func (cs *CommandSet) ApplySync(s *discordgo.Session, plan *SyncPlan) error {
+	if err := plan.validate(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, guildID := range plan.guildIDs() {
+		var ids map[string]string
+		var guildErrs []error
+		if plan.Mode == SyncBulkOverwrite {
+			ids, guildErrs = applyBulkOverwrite(s, plan, guildID)
+		} else {
+			ids, guildErrs = applyIncremental(s, plan, guildID)
+		}
+
+		errs = append(errs, guildErrs...)
+		guild := plan.guild(guildID)
+		if len(guildErrs) > 0 || cs.SyncStore == nil || guild == nil {
+			continue
+		}
+
+		state := &SyncState{Hash: hashAppCommands(guildID, guild.Commands), CommandIDs: ids}
+		if err := cs.SyncStore.Save(plan.AppID, guildID, state); err != nil {
+			errs = append(errs, errors.Wrapf(err, "unable to save sync state for guild '%s'", guildID))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &SyncError{Errors: errs}
+	}
+
+	return nil
+}
+
+// steps the steps of the plan for the guild
+func (p *SyncPlan) steps(guildID string) []SyncStep {
+	var result []SyncStep
+	for _, step := range p.Steps {
+		if step.GuildID == guildID {
+			result = append(result, step)
+		}
+	}
+
+	return result
+}
+
+// knownIDs a copy of the ids of the guild's existing commands
+func (p *SyncPlan) knownIDs(guildID string) map[string]string {
+	ids := make(map[string]string)
+	if guild := p.guild(guildID); guild != nil {
+		for name, id := range guild.CommandIDs {
+			ids[name] = id
+		}
+	}
+
+	return ids
+}
+
+// applyIncremental runs the steps for the guild returning the ids of its commands
+func applyIncremental(s *discordgo.Session, plan *SyncPlan, guildID string) (map[string]string, []error) {
+	ids := plan.knownIDs(guildID)
+
+	var result []error
+	for _, step := range plan.steps(guildID) {
+		var err error
+		switch step.Action {
+		case SyncCreate:
+			var created *AppCommand
+			created, err = createAppCommand(s, plan.AppID, step.GuildID, step.Command)
+			if err == nil {
+				ids[CommandKey(step.Type, step.Name)] = created.ID
+			}
+		case SyncEdit:
+			err = editAppCommand(s, plan.AppID, step.GuildID, step.ID, step.Command)
+		case SyncDelete:
+			err = s.ApplicationCommandDelete(plan.AppID, step.GuildID, step.ID)
+			delete(ids, CommandKey(step.Type, step.Name))
+		}
+
+		if err != nil {
+			result = append(result, errors.Wrapf(err, "unable to %s", step))
+		}
+	}
+
+	return ids, result
+}
+
+// applyBulkOverwrite replaces all the commands in the guild if the plan changes it
+func applyBulkOverwrite(s *discordgo.Session, plan *SyncPlan, guildID string) (map[string]string, []error) {
+	if len(plan.steps(guildID)) == 0 {
+		return plan.knownIDs(guildID), nil
+	}
+
+	// A guild with no commands must be sent as an empty list not null
+	desired := plan.guild(guildID).Commands
+	if desired == nil {
+		desired = []*AppCommand{}
+	}
+
+	cmds, err := bulkOverwriteAppCommands(s, plan.AppID, guildID, desired)
+	if err != nil {
+		return nil, []error{errors.Wrapf(err, "unable to overwrite commands for guild '%s'", guildID)}
+	}
+
+	ids := make(map[string]string)
+	for _, cmd := range cmds {
+		ids[cmd.key()] = cmd.ID
+	}
+
+	return ids, nil
+}
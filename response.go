@@ -0,0 +1,95 @@
+package discom
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+func (r *Response) flags() discordgo.MessageFlags {
+	if r.Ephemeral {
+		return discordgo.MessageFlagsEphemeral
+	}
+
+	return 0
+}
+
+func (r *Response) interactionResponseData() *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		TTS:             r.TTS,
+		Content:         r.Content,
+		Components:      r.Components,
+		Embeds:          r.Embeds,
+		AllowedMentions: r.AllowedMentions,
+		Files:           r.Files,
+		Flags:           r.flags(),
+	}
+}
+
+// webhookEdit embeds and components are only replaced if they are set
+func (r *Response) webhookEdit() *discordgo.WebhookEdit {
+	result := &discordgo.WebhookEdit{
+		Content:         &r.Content,
+		Files:           r.Files,
+		AllowedMentions: r.AllowedMentions,
+	}
+
+	if r.Embeds != nil {
+		result.Embeds = &r.Embeds
+	}
+
+	if r.Components != nil {
+		result.Components = &r.Components
+	}
+
+	return result
+}
+
+func (r *Response) messageSend() *discordgo.MessageSend {
+	return &discordgo.MessageSend{
+		Content:         r.Content,
+		Embeds:          r.Embeds,
+		TTS:             r.TTS,
+		Components:      r.Components,
+		Files:           r.Files,
+		AllowedMentions: r.AllowedMentions,
+	}
+}
+
+// messageEdit embeds and components are only replaced if they are set
+func (r *Response) messageEdit(channelID, messageID string) *discordgo.MessageEdit {
+	result := discordgo.NewMessageEdit(channelID, messageID)
+	result.Content = &r.Content
+	result.Files = r.Files
+	result.AllowedMentions = r.AllowedMentions
+
+	if r.Embeds != nil {
+		result.Embeds = &r.Embeds
+	}
+
+	if r.Components != nil {
+		result.Components = &r.Components
+	}
+
+	return result
+}
+
+func (r *Response) webhookParams() *discordgo.WebhookParams {
+	return &discordgo.WebhookParams{
+		Content:         r.Content,
+		TTS:             r.TTS,
+		Files:           r.Files,
+		Components:      r.Components,
+		Embeds:          r.Embeds,
+		AllowedMentions: r.AllowedMentions,
+		Flags:           r.flags(),
+	}
+}
+
+// continuation the response for the rest of a split message.
+// Embeds, files and components are only sent with the first message.
+func (r *Response) continuation(content string) Response {
+	return Response{
+		Content:         content,
+		Ephemeral:       r.Ephemeral,
+		AllowedMentions: r.AllowedMentions,
+	}
+}
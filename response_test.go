@@ -0,0 +1,75 @@
+package discom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRichResponse(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "rich",
+		Description: "a rich response",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			err := i.Respond(s, Response{
+				Content: "look",
+				Embeds:  []*discordgo.MessageEmbed{{Title: "bee movie"}},
+				Files:   []*discordgo.File{{Name: "bee.txt", ContentType: "text/plain", Reader: strings.NewReader("according to all known laws")}},
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: "buzz", CustomID: "buzz", Style: discordgo.PrimaryButton},
+					}},
+				},
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			})
+			if err != nil {
+				return err
+			}
+
+			return i.Respond(s, Response{Content: "edited"})
+		},
+	}))
+
+	s, transport := newTestSession()
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			AppID: "appID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "rich"},
+		},
+	})
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 2)
+	assert.Contains(t, requests[0].Body, `"title":"bee movie"`)
+	assert.Contains(t, requests[0].Body, `"custom_id":"buzz"`)
+	assert.Contains(t, requests[0].Body, `"allowed_mentions":{"parse":null`)
+	assert.Contains(t, requests[0].Body, `filename="bee.txt"`)
+	assert.Contains(t, requests[0].Body, "according to all known laws")
+	// Embeds are kept when only the content is edited
+	assert.Equal(t, `{"content":"edited"}`, strings.TrimSpace(requests[1].Body))
+
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ rich",
+		},
+	})
+
+	requests = transport.Requests()[2:]
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "POST", requests[0].Method)
+	assert.Contains(t, requests[0].Body, `"title":"bee movie"`)
+	assert.Contains(t, requests[0].Body, `filename="bee.txt"`)
+	assert.Equal(t, "PATCH", requests[1].Method)
+	assert.Contains(t, requests[1].Body, `"content":"edited"`)
+	assert.NotContains(t, requests[1].Body, `"embeds"`)
+}
@@ -0,0 +1,87 @@
+package discom
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type testRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// testTransport records every request made to discord.
+// Unless a response is set for "METHOD path" it replies with an object containing a new id.
+// A status can also be set for "METHOD path" to make the request fail.
+type testTransport struct {
+	mu        sync.Mutex
+	requests  []testRequest
+	responses map[string]string
+	statuses  map[string]int
+	nextID    int
+}
+
+func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+
+	t.requests = append(t.requests, testRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Body:   string(body),
+	})
+
+	status, ok := t.statuses[req.Method+" "+req.URL.Path]
+	if !ok {
+		status = http.StatusOK
+	}
+
+	res, ok := t.responses[req.Method+" "+req.URL.Path]
+	if !ok {
+		t.nextID++
+		res = fmt.Sprintf(`{"id":"%d"}`, t.nextID)
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(res)),
+		Request:    req,
+	}, nil
+}
+
+func (t *testTransport) Requests() []testRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]testRequest(nil), t.requests...)
+}
+
+// newTestSession a session which sends its requests to a testTransport instead of discord
+func newTestSession() (*discordgo.Session, *testTransport) {
+	transport := &testTransport{
+		responses: make(map[string]string),
+		statuses:  make(map[string]int),
+	}
+
+	s := &discordgo.Session{
+		State:       discordgo.NewState(),
+		Ratelimiter: discordgo.NewRatelimiter(),
+		Client:      &http.Client{Transport: transport},
+		UserAgent:   "discom test",
+	}
+	s.State.User = &discordgo.User{ID: "botID"}
+
+	return s, transport
+}
@@ -0,0 +1,139 @@
+package discom
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxMessageLength the most characters discord allows in a message
+const maxMessageLength = 2000
+
+// closeFence appended to a chunk which ends inside a code block
+const closeFence = "\n" + codeFence
+
+// maxFenceLanguage the longest language kept when a code block is reopened
+const maxFenceLanguage = 32
+
+// splitMessage splits content into chunks of at most limit runes.
+// Chunks are broken at a newline or space where possible and code blocks
+// which span chunks are closed and reopened with the same language.
+// Empty content is a single empty chunk.
+func splitMessage(content string, limit int) []string {
+	var result []string
+	openFence := ""
+	for {
+		prefix := reopenPrefix(openFence, limit)
+		if prefix == "" {
+			openFence = ""
+		}
+
+		budget := limit - utf8.RuneCountInString(prefix)
+		if utf8.RuneCountInString(content) <= budget {
+			return append(result, prefix+content)
+		}
+
+		piece, rest := splitAt(content, budget)
+		// Leave room to close the code block if the limit allows it
+		closing := budget-utf8.RuneCountInString(closeFence) >= 1
+		if closing && fenceState(openFence, piece) != "" {
+			piece, rest = splitAt(content, budget-utf8.RuneCountInString(closeFence))
+		}
+
+		openFence = fenceState(openFence, piece)
+		chunk := prefix + piece
+		if openFence != "" && closing {
+			chunk += closeFence
+		}
+
+		result = append(result, chunk)
+		content = rest
+	}
+}
+
+// reopenPrefix the text which reopens the code block open at the start of a chunk.
+// The language is dropped if there isn't room for it and "" is returned if there isn't
+// room to reopen and close the code block around any content.
+func reopenPrefix(open string, limit int) string {
+	if open == "" {
+		return ""
+	}
+
+	for _, prefix := range []string{open + "\n", codeFence + "\n"} {
+		if utf8.RuneCountInString(prefix+closeFence) < limit {
+			return prefix
+		}
+	}
+
+	return ""
+}
+
+// splitAt cuts at most limit runes from the start of content.
+// It prefers the last newline then the last space dropping the character it breaks on.
+func splitAt(content string, limit int) (string, string) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	// Byte index of the rune after the limit
+	end := len(content)
+	count := 0
+	for i := range content {
+		if count == limit {
+			end = i
+			break
+		}
+		count++
+	}
+
+	window := content[:end]
+	if idx := strings.LastIndex(window, "\n"); idx > 0 {
+		return content[:idx], content[idx+1:]
+	}
+
+	if idx := strings.LastIndex(window, " "); idx > 0 {
+		return content[:idx], content[idx+1:]
+	}
+
+	return window, content[end:]
+}
+
+// fenceState returns the opening line of the code block piece ends inside of or "" if it isn't inside one.
+// open is the opening line of the code block piece started inside of.
+func fenceState(open, piece string) string {
+	for {
+		idx := strings.Index(piece, codeFence)
+		if idx == -1 {
+			return open
+		}
+
+		if open != "" {
+			open = ""
+			piece = piece[idx+len(codeFence):]
+			continue
+		}
+
+		// Keep the language of the code block so it is highlighted in the next chunk.
+		// Anything else after the fence is content such as a one line code block.
+		open = codeFence
+		piece = piece[idx+len(codeFence):]
+		if end := strings.Index(piece, "\n"); end != -1 && isFenceLanguage(piece[:end]) {
+			open += piece[:end]
+		}
+	}
+}
+
+// isFenceLanguage reports whether text after a code fence is a language such as go or c++
+func isFenceLanguage(text string) bool {
+	if text == "" || len(text) > maxFenceLanguage {
+		return false
+	}
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("+-#._", r) {
+			return false
+		}
+	}
+
+	return true
+}
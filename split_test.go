@@ -0,0 +1,112 @@
+package discom
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMessage(t *testing.T) {
+	assert.Equal(t, []string{""}, splitMessage("", 10))
+	assert.Equal(t, []string{"bee movie"}, splitMessage("bee movie", 10))
+
+	// Prefers newlines then spaces
+	assert.Equal(t, []string{"bee", "movie is", "good"}, splitMessage("bee\nmovie is good", 10))
+
+	// Multi-byte runes are never cut and nothing is dropped
+	content := strings.Repeat("蜜", 25)
+	chunks := splitMessage(content, 10)
+	assert.Equal(t, content, strings.Join(chunks, ""))
+	for _, chunk := range chunks {
+		assert.True(t, utf8.ValidString(chunk))
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 10)
+	}
+
+	// Code blocks are closed and reopened
+	content = "look\n```go\n" + strings.Repeat("fmt.Println()\n", 5) + "```\ndone"
+	chunks = splitMessage(content, 40)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 40)
+		assert.Equal(t, 0, strings.Count(chunk, codeFence)%2, chunk)
+		if i > 0 && i < len(chunks)-1 {
+			assert.True(t, strings.HasPrefix(chunk, "```go\n"), chunk)
+		}
+	}
+	assert.Equal(t, strings.Count(content, "fmt.Println()"), strings.Count(strings.Join(chunks, ""), "fmt.Println()"))
+
+	// Content on the fence line isn't a language so the code block is reopened bare
+	for _, content := range []string{
+		codeFence + strings.Repeat("z", 2100),
+		"here:\n" + codeFence + "{" + strings.Repeat(`"a":1,`, 400) + `"b":2}` + codeFence,
+	} {
+		chunks = splitMessage(content, maxMessageLength)
+		assert.LessOrEqual(t, len(chunks), 3)
+		assert.True(t, strings.HasPrefix(chunks[len(chunks)-1], codeFence+"\n"), chunks[len(chunks)-1][:10])
+		assert.Equal(t, strings.Count(content, `"a":1,`)+strings.Count(content, "z"), strings.Count(strings.Join(chunks, ""), `"a":1,`)+strings.Count(strings.Join(chunks, ""), "z"))
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), maxMessageLength)
+		}
+	}
+
+	// Limits too small to reopen code blocks still aren't exceeded
+	content = "```go\nfmt.Println()\n```"
+	for limit := 1; limit <= 12; limit++ {
+		chunks = splitMessage(content, limit)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), limit, chunk)
+		}
+	}
+}
+
+func TestLongResponse(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	var respondErr error
+	content := strings.Repeat(strings.Repeat("a", 99)+"\n", 45)
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "long",
+		Description: "a long response",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			respondErr = i.Respond(s, Response{Content: content})
+			return respondErr
+		},
+	}))
+
+	s, transport := newTestSession()
+
+	cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    "interactionID",
+			AppID: "appID",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "long"},
+		},
+	})
+	assert.NoError(t, respondErr)
+
+	requests := transport.Requests()
+	assert.Len(t, requests, 3)
+	assert.Contains(t, requests[0].Path, "/callback")
+	assert.Equal(t, "/api/v9/webhooks/appID/token", requests[1].Path)
+	assert.Equal(t, "/api/v9/webhooks/appID/token", requests[2].Path)
+
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ long",
+		},
+	})
+	assert.NoError(t, respondErr)
+
+	requests = transport.Requests()[3:]
+	assert.Len(t, requests, 3)
+	for _, request := range requests {
+		assert.Equal(t, "POST", request.Method)
+		assert.Equal(t, "/api/v9/channels/channelID/messages", request.Path)
+	}
+}
@@ -0,0 +1,76 @@
+package discom
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// guildIDs the guilds the command is registered in an empty list means globally
+func (cs *CommandSet) guildIDs(cmd *Command) []string {
+	if len(cmd.GuildIDs) > 0 {
+		return cmd.GuildIDs
+	}
+
+	return cs.GuildIDs
+}
+
+// availableIn reports whether the command is registered in the guild
+func (cs *CommandSet) availableIn(cmd *Command, guildID string) bool {
+	guildIDs := cs.guildIDs(cmd)
+	if len(guildIDs) == 0 {
+		return true
+	}
+
+	for _, id := range guildIDs {
+		if id == guildID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// commandsByGuild groups the commands by the guild they are registered in "" being global.
+// Every guild in extraGuildIDs is included even if it has no commands.
+func (cs *CommandSet) commandsByGuild(extraGuildIDs []string) (map[string][]Command, []string) {
+	result := map[string][]Command{"": nil}
+	order := []string{""}
+	add := func(guildID string) {
+		if _, ok := result[guildID]; !ok {
+			result[guildID] = nil
+			order = append(order, guildID)
+		}
+	}
+
+	for _, cmd := range cs.commands {
+		guildIDs := cs.guildIDs(&cmd)
+		if len(guildIDs) == 0 {
+			result[""] = append(result[""], cmd)
+			continue
+		}
+
+		for _, guildID := range guildIDs {
+			add(guildID)
+			result[guildID] = append(result[guildID], cmd)
+		}
+	}
+
+	for _, guildID := range extraGuildIDs {
+		add(guildID)
+	}
+
+	return result, order
+}
+
+// SyncAppCommands registers the commands with discord globally and in the guilds they target.
+// Commands which no longer exist are deleted. Only the global commands, the guilds targeted
+// by a command and the guilds in CommandSet.SyncStore from earlier syncs are changed.
+// Without a SyncStore pass a guild in guildIDs to also clear out a guild which no longer
+// has any commands.
+func (cs *CommandSet) SyncAppCommands(s *discordgo.Session, guildIDs ...string) error {
+	plan, err := cs.PlanSync(s, guildIDs...)
+	if err != nil {
+		return err
+	}
+
+	return cs.ApplySync(s, plan)
+}
@@ -0,0 +1,435 @@
+package discom
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSyncCommandSet(t *testing.T) *CommandSet {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+	handler := func(*discordgo.Session, Interaction) error { return nil }
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "everywhere",
+		Description: "a global command",
+		Handler:     handler,
+	}))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "beta",
+		Description: "a test guild command",
+		Handler:     handler,
+		GuildIDs:    []string{"testGuildID"},
+	}))
+
+	return cs
+}
+
+func TestGuildSync(t *testing.T) {
+	cs := testSyncCommandSet(t)
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"old","description":"stale"}
+	]`
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[]`
+	transport.responses["GET /api/v9/applications/botID/guilds/emptyGuildID/commands"] = `[
+		{"id":"2","application_id":"botID","guild_id":"emptyGuildID","name":"beta","description":"a test guild command"}
+	]`
+	// A guild which isn't a sync target its commands would be deleted if it were synced
+	transport.responses["GET /api/v9/applications/botID/guilds/otherGuildID/commands"] = `[
+		{"id":"3","application_id":"botID","guild_id":"otherGuildID","name":"legacy","description":"left alone"}
+	]`
+
+	assert.NoError(t, cs.SyncAppCommands(s, "emptyGuildID"))
+
+	var changes []string
+	for _, request := range transport.Requests() {
+		if request.Method != "GET" {
+			changes = append(changes, request.Method+" "+request.Path)
+		}
+	}
+
+	assert.Contains(t, changes, "DELETE /api/v9/applications/botID/commands/1")
+	assert.Contains(t, changes, "POST /api/v9/applications/botID/commands")
+	assert.Contains(t, changes, "POST /api/v9/applications/botID/guilds/testGuildID/commands")
+	assert.Contains(t, changes, "DELETE /api/v9/applications/botID/guilds/emptyGuildID/commands/2")
+	for _, request := range transport.Requests() {
+		assert.NotContains(t, request.Path, "/guilds/otherGuildID/")
+	}
+
+	// Guild commands are only available in their guilds for prefix commands
+	assert.Contains(t, cs.getHelpMessage("testGuildID"), `"test$ beta"`)
+	assert.NotContains(t, cs.getHelpMessage("otherGuildID"), `"test$ beta"`)
+	assert.Contains(t, cs.getHelpMessage("otherGuildID"), `"test$ everywhere"`)
+}
+
+func TestPlanSync(t *testing.T) {
+	cs := testSyncCommandSet(t)
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"everywhere","description":"an old description"},
+		{"id":"2","application_id":"botID","name":"old","description":"stale"}
+	]`
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[
+		{"id":"3","application_id":"botID","guild_id":"testGuildID","name":"beta","description":"a test guild command"}
+	]`
+
+	plan, err := cs.PlanSync(s)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Steps, 2)
+	for _, request := range transport.Requests() {
+		assert.Equal(t, "GET", request.Method)
+	}
+
+	edit := plan.Steps[0]
+	assert.Equal(t, SyncEdit, edit.Action)
+	assert.Equal(t, "everywhere", edit.Name)
+	assert.Equal(t, "1", edit.ID)
+	assert.Equal(t, []FieldDiff{
+		{Field: "description", Existing: `"an old description"`, Desired: `"a global command"`},
+	}, edit.Diffs)
+
+	remove := plan.Steps[1]
+	assert.Equal(t, SyncDelete, remove.Action)
+	assert.Equal(t, "old", remove.Name)
+	assert.Equal(t, "2", remove.ID)
+
+	assert.Contains(t, plan.String(), `edit global command "everywhere"`)
+	assert.Contains(t, plan.String(), `delete global command "old"`)
+
+	before := len(transport.Requests())
+	assert.NoError(t, cs.ApplySync(s, plan))
+
+	requests := transport.Requests()[before:]
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "PATCH /api/v9/applications/botID/commands/1", requests[0].Method+" "+requests[0].Path)
+	assert.Equal(t, "DELETE /api/v9/applications/botID/commands/2", requests[1].Method+" "+requests[1].Path)
+
+	// Nothing to do once discord matches
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"everywhere","description":"a global command"}
+	]`
+	plan, err = cs.PlanSync(s)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+	assert.Equal(t, "no changes", plan.String())
+}
+
+func TestSyncModes(t *testing.T) {
+	cs := testSyncCommandSet(t)
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"everywhere","description":"a global command"}
+	]`
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[]`
+	transport.responses["GET /api/v9/applications/botID/guilds/emptyGuildID/commands"] = `[]`
+	transport.responses["PUT /api/v9/applications/botID/guilds/testGuildID/commands"] = `[]`
+
+	changes := func() []testRequest {
+		var result []testRequest
+		for _, request := range transport.Requests() {
+			if request.Method != "GET" {
+				result = append(result, request)
+			}
+		}
+		return result
+	}
+
+	// Incremental only creates the new command
+	assert.NoError(t, cs.SyncAppCommands(s, "emptyGuildID"))
+	requests := changes()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "POST /api/v9/applications/botID/guilds/testGuildID/commands", requests[0].Method+" "+requests[0].Path)
+
+	// Bulk overwrite replaces every command in the changed guilds only
+	cs.SyncMode = SyncBulkOverwrite
+	assert.NoError(t, cs.SyncAppCommands(s, "emptyGuildID"))
+	requests = changes()[1:]
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "PUT /api/v9/applications/botID/guilds/testGuildID/commands", requests[0].Method+" "+requests[0].Path)
+	assert.Contains(t, requests[0].Body, `"name":"beta"`)
+	assert.NotContains(t, requests[0].Body, `"name":"everywhere"`)
+
+	// Failures are collected instead of stopping the sync
+	cs.SyncMode = SyncIncremental
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"everywhere","description":"old"},
+		{"id":"2","application_id":"botID","name":"old","description":"stale"}
+	]`
+	transport.statuses["PATCH /api/v9/applications/botID/commands/1"] = http.StatusForbidden
+	transport.statuses["POST /api/v9/applications/botID/guilds/testGuildID/commands"] = http.StatusForbidden
+	transport.responses["PATCH /api/v9/applications/botID/commands/1"] = `{"code":50001,"message":"Missing Access"}`
+	transport.responses["POST /api/v9/applications/botID/guilds/testGuildID/commands"] = `{"code":50001,"message":"Missing Access"}`
+
+	before := len(changes())
+	err := cs.SyncAppCommands(s)
+	var syncErr *SyncError
+	assert.True(t, errors.As(err, &syncErr))
+	assert.Len(t, syncErr.Errors, 2)
+	assert.Len(t, changes()[before:], 3)
+	assert.Contains(t, err.Error(), `edit global command "everywhere"`)
+}
+
+func TestCommandDiffs(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+	handler := func(*discordgo.Session, Interaction) error { return nil }
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "roll",
+		Description: "roll a dice",
+		Handler:     handler,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Name: "sides", Description: "sides on the dice", Type: discordgo.ApplicationCommandOptionInteger},
+		},
+	}))
+
+	admin := int64(discordgo.PermissionAdministrator)
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:                     "ban",
+		Description:              "ban a user",
+		DescriptionLocalizations: map[discordgo.Locale]string{discordgo.French: "bannir un utilisateur"},
+		Handler:                  handler,
+		DefaultMemberPermissions: &admin,
+		NSFW:                     true,
+		Contexts:                 []InteractionContextType{InteractionContextGuild},
+	}))
+
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:    "Get Info",
+		Type:    discordgo.UserApplicationCommand,
+		Handler: handler,
+	}))
+	assert.Error(t, cs.AddCommand(Command{
+		Name:        "Bad Info",
+		Type:        discordgo.MessageApplicationCommand,
+		Description: "message commands have no description",
+		Handler:     handler,
+	}))
+	assert.NotContains(t, cs.getHelpMessage(""), "Get Info")
+
+	// Commands as discord returns them with its defaults filled in and unset fields left out
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","version":"9","type":1,"name":"roll","description":"roll a dice",
+			"default_member_permissions":null,"dm_permission":true,"nsfw":false,"contexts":null,
+			"options":[{"type":4,"name":"sides","description":"sides on the dice"}]},
+		{"id":"2","application_id":"botID","version":"9","type":1,"name":"ban","description":"ban a user",
+			"default_member_permissions":null,"dm_permission":true,"nsfw":false},
+		{"id":"3","application_id":"botID","version":"9","type":2,"name":"Get Info","description":"",
+			"default_member_permissions":null,"dm_permission":true,"nsfw":false}
+	]`
+
+	plan, err := cs.PlanSync(s)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Steps, 1)
+
+	var fields []string
+	for _, diff := range plan.Steps[0].Diffs {
+		fields = append(fields, diff.Field)
+	}
+	assert.Equal(t, "ban", plan.Steps[0].Name)
+	assert.Equal(t, []string{"description_localizations", "default_member_permissions", "nsfw", "contexts"}, fields)
+
+	before := len(transport.Requests())
+	assert.NoError(t, cs.ApplySync(s, plan))
+	edit := transport.Requests()[before]
+	assert.Equal(t, "PATCH /api/v9/applications/botID/commands/2", edit.Method+" "+edit.Path)
+	assert.Contains(t, edit.Body, `"default_member_permissions":"8"`)
+	assert.Contains(t, edit.Body, `"contexts":[0]`)
+
+	// Unset fields are sent so an edit clears them
+	cs.commands[1].DefaultMemberPermissions = nil
+	body, err := json.Marshal(cs.commands[1].asAppCommand())
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"default_member_permissions":null`)
+}
+
+func TestApplyDecodedPlan(t *testing.T) {
+	cs := testSyncCommandSet(t)
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"everywhere","description":"an old description"}
+	]`
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[
+		{"id":"2","application_id":"botID","guild_id":"testGuildID","name":"old","description":"stale"}
+	]`
+	transport.responses["PUT /api/v9/applications/botID/commands"] = `[
+		{"id":"1","name":"everywhere"}
+	]`
+	transport.responses["PUT /api/v9/applications/botID/guilds/testGuildID/commands"] = `[
+		{"id":"3","name":"beta"}
+	]`
+
+	plan, err := cs.PlanSync(s)
+	assert.NoError(t, err)
+
+	// A plan saved for approval and applied by a different process
+	data, err := json.Marshal(plan)
+	assert.NoError(t, err)
+
+	applier := testSyncCommandSet(t)
+	applier.SyncStore = NewFileSyncStore(filepath.Join(t.TempDir(), "sync.json"))
+
+	var decoded SyncPlan
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	before := len(transport.Requests())
+	assert.NoError(t, applier.ApplySync(s, &decoded))
+
+	var changes []string
+	for _, request := range transport.Requests()[before:] {
+		changes = append(changes, request.Method+" "+request.Path)
+	}
+	assert.Equal(t, []string{
+		"PATCH /api/v9/applications/botID/commands/1",
+		"POST /api/v9/applications/botID/guilds/testGuildID/commands",
+		"DELETE /api/v9/applications/botID/guilds/testGuildID/commands/2",
+	}, changes)
+
+	ids, err := applier.CommandIDs(s, "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"everywhere": "1"}, ids)
+
+	// Bulk overwrite sends the commands from the decoded plan
+	decoded = SyncPlan{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	decoded.Mode = SyncBulkOverwrite
+	before = len(transport.Requests())
+	assert.NoError(t, applier.ApplySync(s, &decoded))
+	requests := transport.Requests()[before:]
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "PUT /api/v9/applications/botID/commands", requests[0].Method+" "+requests[0].Path)
+	assert.Equal(t, "PUT /api/v9/applications/botID/guilds/testGuildID/commands", requests[1].Method+" "+requests[1].Path)
+	assert.Contains(t, requests[1].Body, `"name":"beta"`)
+
+	// A plan written by hand
+	before = len(transport.Requests())
+	assert.NoError(t, applier.ApplySync(s, &SyncPlan{
+		AppID: "botID",
+		Steps: []SyncStep{{Action: SyncDelete, ID: "2"}},
+	}))
+	requests = transport.Requests()[before:]
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "DELETE /api/v9/applications/botID/commands/2", requests[0].Method+" "+requests[0].Path)
+
+	// Plans missing what they need are rejected before anything is changed
+	before = len(transport.Requests())
+	for _, plan := range []*SyncPlan{
+		{Steps: []SyncStep{{Action: SyncDelete, ID: "2"}}},
+		{AppID: "botID", Steps: []SyncStep{{Action: SyncDelete}}},
+		{AppID: "botID", Steps: []SyncStep{{Action: SyncCreate, Name: "new"}}},
+		{AppID: "botID", Mode: SyncBulkOverwrite, Steps: []SyncStep{{Action: SyncDelete, ID: "2"}}},
+	} {
+		assert.True(t, errors.Is(applier.ApplySync(s, plan), ErrInvalidSyncPlan))
+	}
+	assert.Len(t, transport.Requests(), before)
+}
+
+func TestSyncSameNameDifferentTypes(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+	cs.SyncStore = NewFileSyncStore(filepath.Join(t.TempDir(), "sync.json"))
+	handler := func(*discordgo.Session, Interaction) error { return nil }
+
+	assert.NoError(t, cs.AddCommand(Command{Name: "info", Description: "info about the bot", Handler: handler}))
+	assert.NoError(t, cs.AddCommand(Command{Name: "info", Type: discordgo.UserApplicationCommand, Handler: handler}))
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","type":1,"name":"info","description":"info about the bot"},
+		{"id":"2","application_id":"botID","type":3,"name":"info","description":""}
+	]`
+	transport.responses["POST /api/v9/applications/botID/commands"] = `{"id":"3","type":2,"name":"info"}`
+
+	plan, err := cs.PlanSync(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "create global user command \"info\"\ndelete global message command \"info\"", plan.String())
+
+	assert.NoError(t, cs.ApplySync(s, plan))
+	ids, err := cs.CommandIDs(s, "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"info": "1", "user:info": "3"}, ids)
+}
+
+func TestGuildCommandOverridesGlobal(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	var called, completed string
+	command := func(name string) Command {
+		return Command{
+			Name:        "beta",
+			Description: "the " + name + " beta",
+			Handler: func(*discordgo.Session, Interaction) error {
+				called = name
+				return nil
+			},
+			Autocomplete: func(*discordgo.Session, Interaction, *discordgo.ApplicationCommandInteractionDataOption, string) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+				completed = name
+				return nil, nil
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{Name: "feature", Description: "feature", Type: discordgo.ApplicationCommandOptionString, Autocomplete: true},
+			},
+		}
+	}
+
+	assert.NoError(t, cs.AddCommand(command("global")))
+	guild := command("guild")
+	guild.GuildIDs = []string{"testGuildID"}
+	assert.NoError(t, cs.AddCommand(guild))
+
+	s, _ := newTestSession()
+	for guildID, expected := range map[string]string{"testGuildID": "guild", "otherGuildID": "global", "": "global"} {
+		called, completed = "", ""
+		cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:      "interactionID",
+				Token:   "token",
+				GuildID: guildID,
+				Type:    discordgo.InteractionApplicationCommand,
+				Data:    discordgo.ApplicationCommandInteractionData{Name: "beta"},
+			},
+		})
+		assert.Equal(t, expected, called, guildID)
+
+		cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:      "interactionID",
+				Token:   "token",
+				GuildID: guildID,
+				Type:    discordgo.InteractionApplicationCommandAutocomplete,
+				Data: discordgo.ApplicationCommandInteractionData{
+					Name: "beta",
+					Options: []*discordgo.ApplicationCommandInteractionDataOption{
+						{Name: "feature", Type: discordgo.ApplicationCommandOptionString, Value: "b", Focused: true},
+					},
+				},
+			},
+		})
+		assert.Equal(t, expected, completed, guildID)
+
+		called = ""
+		cs.Handler(s, &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				ChannelID: "channelID",
+				GuildID:   guildID,
+				Author:    &discordgo.User{ID: "messagerID"},
+				Content:   "test$ beta",
+			},
+		})
+		assert.Equal(t, expected, called, guildID)
+	}
+
+	help := cs.getHelpMessage("testGuildID")
+	assert.Contains(t, help, "the guild beta")
+	assert.NotContains(t, help, "the global beta")
+	assert.Contains(t, cs.getHelpMessage("otherGuildID"), "the global beta")
+}
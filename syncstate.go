@@ -0,0 +1,170 @@
+package discom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoSyncStore the command ids are only known when CommandSet.SyncStore is set
+	ErrNoSyncStore = fmt.Errorf("command set has no sync store")
+)
+
+// SyncState what was last synced to a guild
+type SyncState struct {
+	// Hash of the commands which were synced
+	Hash string `json:"hash"`
+	// CommandIDs the id discord gave each command by CommandKey
+	CommandIDs map[string]string `json:"command_ids"`
+}
+
+// SyncStore saves the sync state between restarts so unchanged commands aren't synced again.
+// guildID is "" for global commands.
+type SyncStore interface {
+	// Load returns nil if nothing has been saved for the guild
+	Load(appID, guildID string) (*SyncState, error)
+	Save(appID, guildID string, state *SyncState) error
+	// GuildIDs every guild a state has been saved for so guilds which no longer have
+	// any commands are still synced
+	GuildIDs(appID string) ([]string, error)
+}
+
+// FileSyncStore a SyncStore which keeps the state of every guild in one JSON file.
+// This should be created with NewFileSyncStore.
+type FileSyncStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSyncStore a store which keeps its state in the file at path which is created on the first save
+func NewFileSyncStore(path string) *FileSyncStore {
+	return &FileSyncStore{path: path}
+}
+
+func syncStateKey(appID, guildID string) string {
+	return appID + "/" + guildID
+}
+
+func (f *FileSyncStore) read() (map[string]*SyncState, error) {
+	result := make(map[string]*SyncState)
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, errors.Wrapf(err, "invalid sync state file '%s'", f.path)
+	}
+
+	return result, nil
+}
+
+func (f *FileSyncStore) Load(appID, guildID string) (*SyncState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return states[syncStateKey(appID, guildID)], nil
+}
+
+func (f *FileSyncStore) GuildIDs(appID string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for key := range states {
+		if guildID, ok := strings.CutPrefix(key, syncStateKey(appID, "")); ok {
+			result = append(result, guildID)
+		}
+	}
+	sort.Strings(result)
+
+	return result, nil
+}
+
+func (f *FileSyncStore) Save(appID, guildID string, state *SyncState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.read()
+	if err != nil {
+		return err
+	}
+	states[syncStateKey(appID, guildID)] = state
+
+	data, err := json.MarshalIndent(states, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash can't leave the state half written
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// hashAppCommands a hash of the commands which only changes if the commands would be synced differently
+func hashAppCommands(guildID string, cmds []*AppCommand) string {
+	normalised := make([]*AppCommand, len(cmds))
+	for i, cmd := range cmds {
+		normalised[i] = cmd.normalised(guildID)
+	}
+
+	data, _ := json.Marshal(normalised)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CommandIDs the ids of the commands in the guild by CommandKey from the last sync "" being global.
+// Returns ErrNoSyncStore if CommandSet.SyncStore is not set.
+func (cs *CommandSet) CommandIDs(s *discordgo.Session, guildID string) (map[string]string, error) {
+	if cs.SyncStore == nil {
+		return nil, ErrNoSyncStore
+	}
+
+	state, err := cs.SyncStore.Load(s.State.User.ID, guildID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load sync state for guild '%s'", guildID)
+	}
+
+	if state == nil {
+		return map[string]string{}, nil
+	}
+
+	return state.CommandIDs, nil
+}
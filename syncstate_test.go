@@ -0,0 +1,102 @@
+package discom
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncStore(t *testing.T) {
+	cs := testSyncCommandSet(t)
+
+	_, err := cs.CommandIDs(nil, "")
+	assert.True(t, errors.Is(err, ErrNoSyncStore))
+
+	path := filepath.Join(t.TempDir(), "sync.json")
+	cs.SyncStore = NewFileSyncStore(path)
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[
+		{"id":"1","application_id":"botID","name":"everywhere","description":"a global command"}
+	]`
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[]`
+	transport.responses["POST /api/v9/applications/botID/guilds/testGuildID/commands"] = `{"id":"2","name":"beta"}`
+
+	assert.NoError(t, cs.SyncAppCommands(s))
+	assert.Len(t, transport.Requests(), 3)
+
+	ids, err := cs.CommandIDs(s, "")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"everywhere": "1"}, ids)
+
+	ids, err = cs.CommandIDs(s, "testGuildID")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"beta": "2"}, ids)
+
+	// A restart with the same commands doesn't ask discord for anything
+	restarted := testSyncCommandSet(t)
+	restarted.SyncStore = NewFileSyncStore(path)
+	plan, err := restarted.PlanSync(s)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+	assert.Equal(t, []string{"", "testGuildID"}, plan.Unchanged)
+	assert.NoError(t, restarted.ApplySync(s, plan))
+	assert.Len(t, transport.Requests(), 3)
+
+	ids, err = restarted.CommandIDs(s, "testGuildID")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"beta": "2"}, ids)
+
+	// Only the changed guild is synced
+	restarted.commands[0].Description = "a new description"
+	plan, err = restarted.PlanSync(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"testGuildID"}, plan.Unchanged)
+	assert.Len(t, plan.Steps, 1)
+	assert.Equal(t, SyncEdit, plan.Steps[0].Action)
+
+	// State isn't saved for a guild which failed to sync
+	transport.statuses["PATCH /api/v9/applications/botID/commands/1"] = 403
+	assert.Error(t, restarted.ApplySync(s, plan))
+	plan, err = restarted.PlanSync(s)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Steps, 1)
+}
+
+func TestSyncStoreDroppedGuild(t *testing.T) {
+	cs := testSyncCommandSet(t)
+	cs.SyncStore = NewFileSyncStore(filepath.Join(t.TempDir(), "sync.json"))
+
+	s, transport := newTestSession()
+	transport.responses["GET /api/v9/applications/botID/commands"] = `[]`
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[]`
+	transport.responses["POST /api/v9/applications/botID/guilds/testGuildID/commands"] = `{"id":"2","name":"beta"}`
+	assert.NoError(t, cs.SyncAppCommands(s))
+
+	guildIDs, err := cs.SyncStore.GuildIDs("botID")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "testGuildID"}, guildIDs)
+
+	// The guild is no longer targeted by any command but its old commands are still deleted
+	cs.commands = cs.commands[:1]
+	transport.responses["GET /api/v9/applications/botID/guilds/testGuildID/commands"] = `[
+		{"id":"2","application_id":"botID","guild_id":"testGuildID","name":"beta","description":"a test guild command"}
+	]`
+	plan, err := cs.PlanSync(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `delete guild testGuildID command "beta"`, plan.String())
+
+	before := len(transport.Requests())
+	assert.NoError(t, cs.ApplySync(s, plan))
+	requests := transport.Requests()[before:]
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "DELETE /api/v9/applications/botID/guilds/testGuildID/commands/2", requests[0].Method+" "+requests[0].Path)
+
+	// Once cleared out the guild is left alone
+	plan, err = cs.PlanSync(s)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+	assert.Equal(t, []string{"", "testGuildID"}, plan.Unchanged)
+}
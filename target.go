@@ -0,0 +1,53 @@
+package discom
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Target what a user or message command was used on
+type Target struct {
+	ID string
+	// User the user a user command was used on
+	User *discordgo.User
+	// Member the member a user command was used on nil outside of guilds
+	Member *discordgo.Member
+	// Message the message a message command was used on
+	Message *discordgo.Message
+}
+
+func (d *discordInteraction) Target() *Target {
+	if d.interaction.Type != discordgo.InteractionApplicationCommand {
+		return nil
+	}
+
+	data := d.interaction.ApplicationCommandData()
+	if data.TargetID == "" {
+		return nil
+	}
+
+	result := &Target{ID: data.TargetID}
+	if data.Resolved == nil {
+		return result
+	}
+
+	switch data.CommandType {
+	case discordgo.UserApplicationCommand:
+		result.User = data.Resolved.Users[data.TargetID]
+		result.Member = data.Resolved.Members[data.TargetID]
+		// Resolved members are partial and don't include the user
+		if result.Member != nil && result.Member.User == nil {
+			member := *result.Member
+			member.User = result.User
+			result.Member = &member
+		}
+	case discordgo.MessageApplicationCommand:
+		result.Message = data.Resolved.Messages[data.TargetID]
+	}
+
+	return result
+}
+
+// Target prefix commands are never user or message commands
+func (d *discordMessage) Target() *Target {
+	return nil
+}
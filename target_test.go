@@ -0,0 +1,100 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextMenuTarget(t *testing.T) {
+	cs, _ := CreateCommandSet("test$", func(*discordgo.Session, Interaction, error) {})
+
+	var called string
+	var target *Target
+	assert.NoError(t, cs.AddCommand(Command{
+		Name:        "info",
+		Description: "info about the bot",
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			called, target = "chat", i.Target()
+			return nil
+		},
+	}))
+	assert.NoError(t, cs.AddCommand(Command{
+		Name: "info",
+		Type: discordgo.UserApplicationCommand,
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			called, target = "user", i.Target()
+			return nil
+		},
+	}))
+	assert.NoError(t, cs.AddCommand(Command{
+		Name: "info",
+		Type: discordgo.MessageApplicationCommand,
+		Handler: func(s *discordgo.Session, i Interaction) error {
+			called, target = "message", i.Target()
+			return nil
+		},
+	}))
+
+	s, _ := newTestSession()
+	invoke := func(data discordgo.ApplicationCommandInteractionData) {
+		cs.IntreactionHandler(s, &discordgo.InteractionCreate{
+			Interaction: &discordgo.Interaction{
+				ID:    "interactionID",
+				Token: "token",
+				Type:  discordgo.InteractionApplicationCommand,
+				Data:  data,
+			},
+		})
+	}
+
+	user := &discordgo.User{ID: "targetID", Username: "bee"}
+	invoke(discordgo.ApplicationCommandInteractionData{
+		Name:        "info",
+		CommandType: discordgo.UserApplicationCommand,
+		TargetID:    "targetID",
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Users:   map[string]*discordgo.User{"targetID": user},
+			Members: map[string]*discordgo.Member{"targetID": {Nick: "barry"}},
+		},
+	})
+	assert.Equal(t, "user", called)
+	assert.Equal(t, "targetID", target.ID)
+	assert.Equal(t, user, target.User)
+	assert.Equal(t, "barry", target.Member.Nick)
+	assert.Equal(t, user, target.Member.User)
+	assert.Nil(t, target.Message)
+
+	message := &discordgo.Message{ID: "messageID", Content: "according to all known laws of aviation"}
+	invoke(discordgo.ApplicationCommandInteractionData{
+		Name:        "info",
+		CommandType: discordgo.MessageApplicationCommand,
+		TargetID:    "messageID",
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Messages: map[string]*discordgo.Message{"messageID": message},
+		},
+	})
+	assert.Equal(t, "message", called)
+	assert.Equal(t, message, target.Message)
+	assert.Nil(t, target.User)
+
+	invoke(discordgo.ApplicationCommandInteractionData{
+		Name:        "info",
+		CommandType: discordgo.ChatApplicationCommand,
+	})
+	assert.Equal(t, "chat", called)
+	assert.Nil(t, target)
+
+	// Prefix mode only runs chat commands
+	called = ""
+	cs.Handler(s, &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "channelID",
+			Author:    &discordgo.User{ID: "messagerID"},
+			Content:   "test$ info",
+		},
+	})
+	assert.Equal(t, "chat", called)
+	assert.Nil(t, target)
+}
@@ -0,0 +1,99 @@
+package discom
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnterminatedQuote     = fmt.Errorf("unterminated quote")
+	ErrUnterminatedCodeBlock = fmt.Errorf("unterminated code block")
+	ErrTrailingEscape        = fmt.Errorf("trailing escape character")
+)
+
+const codeFence = "```"
+
+// tokenize splits a prefix command message into arguments the way a shell would.
+// Whitespace (including newlines) separates arguments, double quotes group text and
+// allow backslash escapes, single quotes at the start of an argument group text literally
+// and fenced code blocks are kept verbatim as a single argument. An apostrophe inside a
+// word such as it's is kept as is.
+func tokenize(input string) ([]string, error) {
+	var (
+		result  []string
+		current strings.Builder
+		// inToken is needed so "" produces an empty argument
+		inToken bool
+	)
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			if inToken {
+				result = append(result, current.String())
+				current.Reset()
+				inToken = false
+			}
+
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, errors.Wrapf(ErrTrailingEscape, "at position %d", i)
+			}
+			i++
+			current.WriteRune(runes[i])
+			inToken = true
+
+		case r == '"':
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.Wrapf(ErrUnterminatedQuote, "double quote opened at position %d", start)
+			}
+			inToken = true
+
+		case r == '\'' && !inToken:
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				current.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.Wrapf(ErrUnterminatedQuote, "single quote opened at position %d", start)
+			}
+			inToken = true
+
+		case strings.HasPrefix(string(runes[i:]), codeFence):
+			start := i
+			end := strings.Index(string(runes[i+len(codeFence):]), codeFence)
+			if end == -1 {
+				return nil, errors.Wrapf(ErrUnterminatedCodeBlock, "code block opened at position %d", start)
+			}
+			block := codeFence + string(runes[i+len(codeFence):])[:end] + codeFence
+			current.WriteString(block)
+			i += len([]rune(block)) - 1
+			inToken = true
+
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		result = append(result, current.String())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package discom
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"nice", []string{"nice"}},
+		{" nice  -moive   bee ", []string{"nice", "-moive", "bee"}},
+		{"nice\n-moive\tbee", []string{"nice", "-moive", "bee"}},
+		{`nice -title "The Bee Movie"`, []string{"nice", "-title", "The Bee Movie"}},
+		{`nice -title 'The "Bee" Movie'`, []string{"nice", "-title", `The "Bee" Movie`}},
+		{`nice -title "The \"Bee\" Movie"`, []string{"nice", "-title", `The "Bee" Movie`}},
+		{`nice -title The\ Bee`, []string{"nice", "-title", "The Bee"}},
+		{`nice -empty ""`, []string{"nice", "-empty", ""}},
+		{`nice -mixed ab"c d"e`, []string{"nice", "-mixed", "abc de"}},
+		{"nice -code ```go\nfmt.Println(\"hi there\")\n```", []string{"nice", "-code", "```go\nfmt.Println(\"hi there\")\n```"}},
+		{"nice -title 蜜蜂 电影", []string{"nice", "-title", "蜜蜂", "电影"}},
+		{"say it's fine", []string{"say", "it's", "fine"}},
+		{"say don't 'quote me'", []string{"say", "don't", "quote me"}},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		result, err := tokenize(test.input)
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, result, test.input)
+	}
+
+	_, err := tokenize(`nice -title "The Bee Movie`)
+	assert.True(t, errors.Is(err, ErrUnterminatedQuote))
+
+	_, err = tokenize(`nice -title 'The Bee Movie`)
+	assert.True(t, errors.Is(err, ErrUnterminatedQuote))
+
+	_, err = tokenize("nice -code ```go\nfmt.Println()")
+	assert.True(t, errors.Is(err, ErrUnterminatedCodeBlock))
+
+	_, err = tokenize(`nice -title bee\`)
+	assert.True(t, errors.Is(err, ErrTrailingEscape))
+}